@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/theHamdiz/gun/internal/generator"
+	"github.com/theHamdiz/it"
+)
+
+var destroyCmd = &cobra.Command{
+	Use:   "destroy [kind] [name]",
+	Short: "Remove the files created by a generate command (model, handler, route, view, middleware, migration, repository or a plugin)",
+	Long: `Destroy reverses 'gun generate <kind> <name>' by removing exactly the files that generator
+recorded in .gun/manifest.json. Files edited since they were generated are handled with --on-conflict:
+prompt asks, skip keeps them, force deletes them and backup keeps a *.orig copy.`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		kind, name := args[0], args[1]
+		if _, err := loadProject(cmd); err != nil {
+			return err
+		}
+
+		err := generator.Destroy(kind, name)
+		if err != nil {
+			return err
+		}
+
+		it.Infof("%s '%s' destroyed successfully!\n", kind, name)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(destroyCmd)
+}
@@ -1,7 +1,12 @@
 package cmd
 
 import (
+	"os"
+
 	"github.com/spf13/cobra"
+	"github.com/theHamdiz/gun/internal/config"
+	"github.com/theHamdiz/gun/internal/generator"
+	"github.com/theHamdiz/gun/internal/inflect"
 )
 
 var generateCmd = &cobra.Command{
@@ -11,4 +16,53 @@ var generateCmd = &cobra.Command{
 
 func init() {
 	rootCmd.AddCommand(generateCmd)
+
+	// These override the project configuration for a single run
+	generateCmd.PersistentFlags().String("style", "", "Styling framework generated views target (defaults to the project config)")
+	generateCmd.PersistentFlags().String("router", "", "Router backend generated code targets (defaults to the project config)")
+	generateCmd.PersistentFlags().String("dialect", "", "SQL database generated migrations and queries target (defaults to the project config)")
+}
+
+// loadProject finds the project the current directory belongs to, and moves to its root
+// so that every generator writes relative to it no matter where gun was run from.
+// Flags given on the command line override the project configuration.
+func loadProject(cmd *cobra.Command) (*generator.ProjectContext, error) {
+	wd, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+	ctx, err := generator.FindProject(wd)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chdir(ctx.Root); err != nil {
+		return nil, err
+	}
+	if err := applyConfigFlags(cmd, &ctx.Config); err != nil {
+		return nil, err
+	}
+
+	generator.UseProjectTemplates(ctx.Root)
+	inflect.Configure(ctx.Config.Inflections)
+	if err := generator.UsePack(ctx.Config.Router); err != nil {
+		return nil, err
+	}
+	return ctx, nil
+}
+
+func applyConfigFlags(cmd *cobra.Command, cfg *config.Config) error {
+	overrides := map[string]*string{
+		"style":   &cfg.Style,
+		"router":  &cfg.Router,
+		"dialect": &cfg.Dialect,
+	}
+	for name, value := range overrides {
+		if flag := cmd.Flags().Lookup(name); flag != nil && flag.Changed {
+			*value = flag.Value.String()
+		}
+	}
+	if flag := cmd.Flags().Lookup("tags"); flag != nil && flag.Changed {
+		cfg.Tags, _ = cmd.Flags().GetStringSlice("tags")
+	}
+	return cfg.Validate()
 }
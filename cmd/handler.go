@@ -11,9 +11,14 @@ var handlerCmd = &cobra.Command{
 	Short: "Generate handlers for a resource",
 	Args:  cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, err := loadProject(cmd)
+		if err != nil {
+			return err
+		}
+
 		resourceName := args[0]
 
-		err := generator.GenerateHandler(resourceName)
+		err = generator.GenerateHandler(ctx, resourceName)
 		if err != nil {
 			return err
 		}
@@ -11,9 +11,14 @@ var middlewareCmd = &cobra.Command{
 	Short: "Generate a middleware",
 	Args:  cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, err := loadProject(cmd)
+		if err != nil {
+			return err
+		}
+
 		middlewareName := args[0]
 
-		err := generator.GenerateMiddleware(middlewareName)
+		err = generator.GenerateMiddleware(ctx, middlewareName)
 		if err != nil {
 			return err
 		}
@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/theHamdiz/gun/internal/generator"
+	"github.com/theHamdiz/it"
+)
+
+var migrationCmd = &cobra.Command{
+	Use:   "migration [name]",
+	Short: "Generate an empty SQL migration, like add_slug_to_posts",
+	Long: `Migration writes a pair of timestamped files to internal/db/migrations, one applying the
+migration and one reverting it, for the statements to write by hand. Models get their migrations
+from 'gun generate model', in the SQL dialect of the project.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, err := loadProject(cmd)
+		if err != nil {
+			return err
+		}
+
+		migrationName := args[0]
+
+		err = generator.GenerateMigration(ctx, migrationName)
+		if err != nil {
+			return err
+		}
+
+		it.Infof("Migration '%s' created successfully!\n", migrationName)
+		return nil
+	},
+}
+
+func init() {
+	generateCmd.AddCommand(migrationCmd)
+}
@@ -1,23 +1,88 @@
 package cmd
 
 import (
-	"strings"
+	"context"
+	"errors"
+	"fmt"
+	"os"
 
 	"github.com/spf13/cobra"
 	"github.com/theHamdiz/gun/internal/generator"
+	"github.com/theHamdiz/gun/internal/introspect"
 	"github.com/theHamdiz/it"
 )
 
 var modelCmd = &cobra.Command{
 	Use:   "model [name]",
-	Short: "Generate a model",
-	Args:  cobra.ExactArgs(1),
+	Short: "Generate a model, or models read from a SQL schema, a database, a JSON sample or a JSON Schema",
+	Args:  cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, err := loadProject(cmd)
+		if err != nil {
+			return err
+		}
+
+		id, _ := cmd.Flags().GetString("id")
+		timestamps, _ := cmd.Flags().GetBool("timestamps")
+		softDelete, _ := cmd.Flags().GetBool("soft-delete")
+		opts := generator.ModelOptions{ID: id, Timestamps: timestamps, SoftDelete: softDelete}
+		if err := opts.Check(); err != nil {
+			return err
+		}
+		if opts != (generator.ModelOptions{}) {
+			for _, from := range []string{"from-sql", "from-db", "from-json", "from-jsonschema"} {
+				if cmd.Flags().Changed(from) {
+					return fmt.Errorf("--id, --timestamps and --soft-delete apply to models defined with --fields, not --%s", from)
+				}
+			}
+		}
+
+		tables, _ := cmd.Flags().GetStringSlice("table")
+		scaffold, _ := cmd.Flags().GetBool("scaffold")
+		if fromSQL, _ := cmd.Flags().GetString("from-sql"); fromSQL != "" {
+			if len(args) > 0 {
+				return errors.New("models read with --from-sql are named after their tables, select them with --table")
+			}
+			return generateModelsFromSQL(ctx, fromSQL, tables, scaffold)
+		}
+		if fromDB, _ := cmd.Flags().GetString("from-db"); fromDB != "" {
+			if len(args) > 0 {
+				return errors.New("models read with --from-db are named after their tables, select them with --table")
+			}
+			return generateModelsFromDB(cmd.Context(), ctx, fromDB, tables, scaffold)
+		}
+		if scaffold {
+			return errors.New("--scaffold needs --from-sql or --from-db")
+		}
+		if fromJSON, _ := cmd.Flags().GetString("from-json"); fromJSON != "" {
+			if len(args) == 0 {
+				return errors.New("missing the name of the model the JSON sample describes")
+			}
+			return generateModelsFromFile(ctx, fromJSON, func(data []byte) ([]generator.ModelDefinition, error) {
+				return generator.ModelsFromJSON(args[0], data)
+			})
+		}
+		if fromSchema, _ := cmd.Flags().GetString("from-jsonschema"); fromSchema != "" {
+			name := ""
+			if len(args) > 0 {
+				name = args[0]
+			}
+			return generateModelsFromFile(ctx, fromSchema, func(data []byte) ([]generator.ModelDefinition, error) {
+				return generator.ModelsFromJSONSchema(name, data)
+			})
+		}
+		if len(args) == 0 {
+			return errors.New("missing the model name")
+		}
+
 		modelName := args[0]
 		fieldsStr, _ := cmd.Flags().GetString("fields")
-		fields := parseFields(fieldsStr)
+		fields, err := generator.ParseFields(fieldsStr)
+		if err != nil {
+			return err
+		}
 
-		err := generator.GenerateModel(modelName, fields)
+		err = generator.GenerateModel(ctx, modelName, fields, opts)
 		if err != nil {
 			return err
 		}
@@ -27,27 +92,94 @@ var modelCmd = &cobra.Command{
 	},
 }
 
-func init() {
-	generateCmd.AddCommand(modelCmd)
-	modelCmd.Flags().String("fields", "", "Fields for the model (e.g., 'Name:string Email:string')")
+func generateModelsFromSQL(ctx *generator.ProjectContext, path string, tables []string, scaffold bool) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	defs, err := generator.ModelsFromSQL(string(data), ctx.Config.Dialect, tables)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return generateModelDefinitions(ctx, defs, scaffold)
 }
 
-func parseFields(fieldsStr string) []generator.Field {
-	var fields []generator.Field
-	if fieldsStr == "" {
-		return fields
+// generateModelsFromDB generates the models of the tables of a live database, like sqlite:./app.db.
+func generateModelsFromDB(c context.Context, ctx *generator.ProjectContext, url string, only []string, scaffold bool) error {
+	db, err := introspect.Open(url)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	tables, err := db.Tables(c)
+	if err != nil {
+		return fmt.Errorf("%s: %w", url, err)
 	}
+	defs, err := generator.ModelsFromTables(tables, only)
+	if err != nil {
+		return fmt.Errorf("%s: %w", url, err)
+	}
+	return generateModelDefinitions(ctx, defs, scaffold)
+}
 
-	pairs := strings.Split(fieldsStr, " ")
-	for _, pair := range pairs {
-		parts := strings.Split(pair, ":")
-		if len(parts) != 2 {
-			continue
+// generateModelsFromFile generates the models read from the file at path.
+func generateModelsFromFile(ctx *generator.ProjectContext, path string, read func([]byte) ([]generator.ModelDefinition, error)) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	defs, err := read(data)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return generateModelDefinitions(ctx, defs, false)
+}
+
+// generateModelDefinitions generates models, and with scaffold the handlers, routes and views
+// of each of them.
+func generateModelDefinitions(ctx *generator.ProjectContext, defs []generator.ModelDefinition, scaffold bool) error {
+	if err := generator.GenerateModels(ctx, defs); err != nil {
+		return err
+	}
+	for _, def := range defs {
+		if def.Table != "" {
+			it.Infof("Model '%s' created from table '%s'!\n", def.Name, def.Table)
+		} else {
+			it.Infof("Model '%s' created successfully!\n", def.Name)
 		}
-		fields = append(fields, generator.Field{
-			Name: parts[0],
-			Type: parts[1],
-		})
 	}
-	return fields
+	if !scaffold {
+		return nil
+	}
+
+	for _, def := range defs {
+		if err := generator.GenerateHandler(ctx, def.Name); err != nil {
+			return fmt.Errorf("handler %s: %w", def.Name, err)
+		}
+		if err := generator.GenerateRoute(ctx, def.Name, false); err != nil {
+			return fmt.Errorf("route %s: %w", def.Name, err)
+		}
+		if err := generator.GenerateViews(ctx, def.Name, nil); err != nil {
+			return fmt.Errorf("views %s: %w", def.Name, err)
+		}
+		it.Infof("Handler, route and views for '%s' created successfully!\n", def.Name)
+	}
+	return nil
+}
+
+func init() {
+	generateCmd.AddCommand(modelCmd)
+	modelCmd.Flags().StringSlice("tags", nil, "Struct tags of the model, as name[:style] (defaults to the project config, e.g. json:camel,db,validate)")
+	modelCmd.Flags().String("fields", "", "Fields for the model (e.g., 'Name:string:required Email:string:unique:email Age:int?:min=0')")
+	modelCmd.Flags().String("id", "", "Generate the primary key of the model: int, uuid or ulid")
+	modelCmd.Flags().Bool("timestamps", false, "Add CreatedAt and UpdatedAt, maintained by the data layer")
+	modelCmd.Flags().Bool("soft-delete", false, "Add DeletedAt: deleted records are kept, left out of queries, and can be restored")
+	modelCmd.Flags().String("from-sql", "", "Generate a model for every CREATE TABLE statement of a SQL file (Postgres, MySQL or SQLite)")
+	modelCmd.Flags().String("from-db", "", "Generate a model for every table of a database, as scheme:address (e.g. sqlite:./app.db)")
+	modelCmd.Flags().StringSlice("table", nil, "Only generate the models of these tables with --from-sql or --from-db")
+	modelCmd.Flags().Bool("scaffold", false, "Also generate the handlers, routes and views of the models read with --from-sql or --from-db")
+	modelCmd.Flags().String("from-json", "", "Infer the model and its nested models from a sample JSON payload")
+	modelCmd.Flags().String("from-jsonschema", "", "Generate the model and its nested models from a JSON Schema (named after its title unless a name is given)")
+	modelCmd.MarkFlagsMutuallyExclusive("fields", "from-sql", "from-db", "from-json", "from-jsonschema")
 }
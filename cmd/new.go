@@ -1,7 +1,10 @@
 package cmd
 
 import (
+	"strings"
+
 	"github.com/spf13/cobra"
+	"github.com/theHamdiz/gun/internal/config"
 	"github.com/theHamdiz/gun/internal/project"
 	"github.com/theHamdiz/it"
 )
@@ -24,12 +27,24 @@ var newProjectCmd = &cobra.Command{
 		style, _ := cmd.Flags().GetString("style")
 		withChannels, _ := cmd.Flags().GetBool("with-channels")
 		withSignals, _ := cmd.Flags().GetBool("with-signals")
+		router, _ := cmd.Flags().GetString("router")
+		tags, _ := cmd.Flags().GetStringSlice("tags")
+		dialect, _ := cmd.Flags().GetString("dialect")
 		moduleName, err := cmd.Flags().GetString("module-name")
 		if err != nil {
 			// If the module name is not specified, use the project name
 			moduleName = projectName
 		}
-		it.Ensure(project.CreateProject(projectName, moduleName, style, withChannels, withSignals))
+		it.Ensure(project.CreateProject(project.Project{
+			Name:         projectName,
+			ModuleName:   moduleName,
+			Style:        style,
+			Router:       router,
+			Dialect:      dialect,
+			Tags:         tags,
+			WithChannels: withChannels,
+			WithSignals:  withSignals,
+		}))
 
 		it.Infof("Project '%s' created successfully!\n", projectName)
 		it.Infof("Install dependencies with -> 'gun install'\n")
@@ -44,5 +59,8 @@ func init() {
 	newProjectCmd.Flags().String("style", "tailwind", "Styling framework to use (tailwind, shadcn, bootstrap, daisyui, preline, flowbite)")
 	newProjectCmd.Flags().Bool("with-channels", false, "Include channel utilities")
 	newProjectCmd.Flags().Bool("with-signals", false, "Include signal handling utilities")
+	newProjectCmd.Flags().String("router", "fiber", "Router backend generated code targets ("+strings.Join(config.Routers, ", ")+")")
+	newProjectCmd.Flags().String("dialect", config.Default().Dialect, "SQL database generated migrations target ("+strings.Join(config.Dialects, ", ")+")")
+	newProjectCmd.Flags().StringSlice("tags", config.Default().Tags, "Struct tags of generated models, as name[:style] (e.g. json:camel,db,validate)")
 	newProjectCmd.Flags().String("module-name", "github.com/theHamdiz/MyApp", "Specify the module name separately")
 }
@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/theHamdiz/gun/internal/generator"
+	"github.com/theHamdiz/it"
+)
+
+// registerPlugins adds a generate subcommand for every gun-<name> plugin found in the
+// project's .gun/plugins directory or on PATH. Built-in generators always take precedence.
+func registerPlugins() {
+	root := ""
+	if wd, err := os.Getwd(); err == nil {
+		if ctx, err := generator.FindProject(wd); err == nil {
+			root = ctx.Root
+		}
+	}
+
+	builtin := map[string]bool{}
+	for _, c := range generateCmd.Commands() {
+		builtin[c.Name()] = true
+	}
+
+	for _, p := range generator.FindPlugins(root) {
+		if builtin[p.Name] {
+			continue
+		}
+		generateCmd.AddCommand(pluginCommand(p))
+	}
+}
+
+func pluginCommand(p generator.Plugin) *cobra.Command {
+	return &cobra.Command{
+		Use:   p.Name + " [args...]",
+		Short: "Generate with the " + generator.PluginPrefix + p.Name + " plugin (" + p.Path + ")",
+		Long: `Runs the plugin with the project context and arguments as JSON on stdin, and writes the files it
+returns like any built-in generator. Arguments after -- are passed to the plugin untouched.`,
+		Args: cobra.ArbitraryArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, err := loadProject(cmd)
+			if err != nil {
+				return err
+			}
+
+			err = generator.RunPlugin(ctx, p, args)
+			if err != nil {
+				return err
+			}
+
+			it.Infof("Plugin '%s' ran successfully!\n", p.Name)
+			return nil
+		},
+	}
+}
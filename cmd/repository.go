@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/theHamdiz/gun/internal/generator"
+	"github.com/theHamdiz/it"
+)
+
+var repositoryCmd = &cobra.Command{
+	Use:   "repository [model]",
+	Short: "Generate the repository storing a model, with database/sql and in-memory implementations",
+	Long: `Repository writes a UserRepository interface for the User model, with context-aware
+Create, Get, List, Update and Delete methods, SQLUserRepository, which implements it with
+database/sql in the SQL dialect of the project, and MemoryUserRepository, which keeps users in
+memory for tests and prototypes. With --memory, only the in-memory implementation is written.
+The model must have been generated with gun.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, err := loadProject(cmd)
+		if err != nil {
+			return err
+		}
+
+		modelName := args[0]
+		memory, _ := cmd.Flags().GetBool("memory")
+
+		err = generator.GenerateRepository(ctx, modelName, memory)
+		if err != nil {
+			return err
+		}
+
+		it.Infof("Repository '%s' created successfully!\n", modelName)
+		return nil
+	},
+}
+
+func init() {
+	generateCmd.AddCommand(repositoryCmd)
+
+	repositoryCmd.Flags().Bool("memory", false, "Only generate the in-memory implementation, for apps without a database yet")
+}
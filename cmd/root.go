@@ -4,6 +4,7 @@ import (
 	"os"
 
 	"github.com/spf13/cobra"
+	"github.com/theHamdiz/gun/internal/generator"
 	"github.com/theHamdiz/it"
 )
 
@@ -11,11 +12,34 @@ var rootCmd = &cobra.Command{
 	Use:   "gun",
 	Short: "Gun is a CLI tool for generating Go project boilerplate code.",
 	Long:  `Gun helps you quickly scaffold Go projects with Fiber, Tailwind CSS, and other modern tools.`,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		generator.SetDryRun(dryRun)
+
+		onConflict, _ := cmd.Flags().GetString("on-conflict")
+		policy, err := generator.ParseConflictPolicy(onConflict)
+		if err != nil {
+			return err
+		}
+		generator.SetConflictPolicy(policy)
+		return nil
+	},
+	PersistentPostRun: func(cmd *cobra.Command, args []string) {
+		if generator.IsDryRun() {
+			generator.PrintPlan(cmd.OutOrStdout())
+		}
+	},
 }
 
 func Execute() {
+	registerPlugins()
 	if err := rootCmd.Execute(); err != nil {
 		it.LogErrorWithStack(err)
 		os.Exit(1)
 	}
 }
+
+func init() {
+	rootCmd.PersistentFlags().Bool("dry-run", false, "Print the files that would be written, with a diff, without touching the disk")
+	rootCmd.PersistentFlags().String("on-conflict", string(generator.ConflictPrompt), "What to do when a generated file already exists (skip, force, prompt, backup, merge)")
+}
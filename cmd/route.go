@@ -8,12 +8,18 @@ import (
 
 var routeCmd = &cobra.Command{
 	Use:   "route [resource]",
-	Short: "Generate routes for a resource",
+	Short: "Generate routes for a resource and register them in the router",
 	Args:  cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, err := loadProject(cmd)
+		if err != nil {
+			return err
+		}
+
 		resourceName := args[0]
+		api, _ := cmd.Flags().GetBool("api")
 
-		err := generator.GenerateRoute(resourceName)
+		err = generator.GenerateRoute(ctx, resourceName, api)
 		if err != nil {
 			return err
 		}
@@ -25,4 +31,6 @@ var routeCmd = &cobra.Command{
 
 func init() {
 	generateCmd.AddCommand(routeCmd)
+
+	routeCmd.Flags().Bool("api", false, "Register the routes under the versioned API (RegisterAPIV1) instead of RegisterRoutes")
 }
@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/theHamdiz/gun/internal/generator"
+	"github.com/theHamdiz/it"
+)
+
+var templatesCmd = &cobra.Command{
+	Use:   "templates",
+	Short: "List and customize the templates gun generates code from",
+	Long: `Gun looks for .gun/templates/<name>.tmpl in the project, then for <name>.tmpl in the
+user-level templates directory, before falling back to its built-in templates.`,
+}
+
+var templatesListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the templates and show which ones are overridden",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// Outside a project only the user-level overrides apply
+		if _, err := loadProject(cmd); err != nil {
+			it.Warnf("Not inside a project, showing user-level overrides only: %v", err)
+		}
+
+		w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintln(w, "NAME\tSOURCE\tPATH")
+		for _, name := range generator.TemplateNames() {
+			source, path, err := generator.TemplateOrigin(name)
+			if err != nil {
+				return err
+			}
+			if path == "" {
+				path = "-"
+			}
+			if source == generator.SourceBuiltin {
+				source += generator.TemplateSource(" (" + generator.ActivePack().Name + ")")
+			}
+			_, _ = fmt.Fprintf(w, "%s\t%s\t%s\n", name, source, path)
+		}
+		return w.Flush()
+	},
+}
+
+var templatesEjectCmd = &cobra.Command{
+	Use:   "eject [name]",
+	Short: "Write built-in templates to .gun/templates for editing (all of them when no name is given)",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		user, _ := cmd.Flags().GetBool("user")
+
+		dir := generator.ProjectTemplatesDir
+		if user {
+			userDir, err := generator.UserTemplatesDir()
+			if err != nil {
+				return err
+			}
+			dir = userDir
+		} else if _, err := loadProject(cmd); err != nil {
+			return err
+		}
+
+		paths, err := generator.EjectTemplates(dir, args...)
+		if err != nil {
+			return err
+		}
+
+		for _, path := range paths {
+			it.Infof("Ejected %s", filepath.ToSlash(path))
+		}
+		return nil
+	},
+}
+
+func init() {
+	templatesCmd.AddCommand(templatesListCmd)
+	templatesCmd.AddCommand(templatesEjectCmd)
+	rootCmd.AddCommand(templatesCmd)
+
+	templatesEjectCmd.Flags().Bool("user", false, "Eject into the user-level templates directory instead of the project")
+}
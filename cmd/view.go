@@ -11,11 +11,19 @@ var viewCmd = &cobra.Command{
 	Short: "Generate views for a resource",
 	Args:  cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, err := loadProject(cmd)
+		if err != nil {
+			return err
+		}
+
 		resourceName := args[0]
 		fieldsStr, _ := cmd.Flags().GetString("fields")
-		fields := parseFields(fieldsStr)
+		fields, err := generator.ParseFields(fieldsStr)
+		if err != nil {
+			return err
+		}
 
-		err := generator.GenerateViews(resourceName, fields)
+		err = generator.GenerateViews(ctx, resourceName, fields)
 		if err != nil {
 			return err
 		}
@@ -27,5 +35,5 @@ var viewCmd = &cobra.Command{
 
 func init() {
 	generateCmd.AddCommand(viewCmd)
-	viewCmd.Flags().String("fields", "", "Fields for the resource (e.g., 'Name:string Email:string')")
+	viewCmd.Flags().String("fields", "", "Fields for the resource (e.g., 'Name:string:required Email:string:email')")
 }
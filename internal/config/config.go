@@ -0,0 +1,131 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/theHamdiz/gun/internal/inflect"
+)
+
+// Path is where the project configuration lives, relative to the project root.
+var Path = filepath.Join(".gun", "config.json")
+
+// Routers lists the router backends generators can target.
+var Routers = []string{"fiber", "nethttp"}
+
+// Dialects lists the SQL databases generated migrations and queries target.
+var Dialects = []string{"postgres", "mysql", "sqlite"}
+
+// TagStyles lists the naming styles a struct tag can derive from a field name.
+var TagStyles = []string{"snake", "camel", "pascal", "kebab", "lower"}
+
+// TagDefaults lists the struct tags generated models can carry, with the naming style each
+// uses when none is given. validate has no style, it is made of the field's rules.
+var TagDefaults = map[string]string{
+	"json":     "camel",
+	"db":       "snake",
+	"gorm":     "snake",
+	"validate": "",
+	"form":     "snake",
+	"xml":      "camel",
+	"bson":     "snake",
+}
+
+// Config holds the choices made when the project was created.
+// Generators read it as their defaults, and command line flags override it.
+type Config struct {
+	Name         string `json:"name"`
+	ModuleName   string `json:"module_name"`
+	Style        string `json:"style"`
+	Router       string `json:"router"`
+	WithChannels bool   `json:"with_channels"`
+	WithSignals  bool   `json:"with_signals"`
+	// Dialect is the SQL database of the project, one of Dialects.
+	Dialect string `json:"dialect,omitempty"`
+	// Tags are the struct tags of generated models, as name[:style] like "json:camel".
+	Tags []string `json:"tags"`
+	// Inflections add acronyms, irregular plurals and uncountable words to the built-in ones.
+	Inflections inflect.Rules `json:"inflections,omitempty"`
+}
+
+// Default returns the configuration used for projects that have no config file.
+func Default() Config {
+	return Config{
+		Style:   "tailwind",
+		Router:  "fiber",
+		Dialect: "sqlite",
+		Tags:    []string{"json:snake", "db:snake"},
+	}
+}
+
+// Load reads the configuration of the project at root, falling back to the defaults
+// for a missing file or missing keys.
+func Load(root string) (Config, error) {
+	cfg := Default()
+	data, err := os.ReadFile(filepath.Join(root, Path))
+	if errors.Is(err, fs.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return cfg, err
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("invalid %s: %w", filepath.ToSlash(Path), err)
+	}
+	return cfg, cfg.Validate()
+}
+
+// Marshal renders the configuration the way it is stored on disk.
+func (c Config) Marshal() ([]byte, error) {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// Validate checks the values that generators depend on.
+func (c Config) Validate() error {
+	if !slices.Contains(Routers, strings.ToLower(c.Router)) {
+		return fmt.Errorf("unsupported router %q (expected one of %s)", c.Router, strings.Join(Routers, ", "))
+	}
+	if !slices.Contains(Dialects, strings.ToLower(c.Dialect)) {
+		return fmt.Errorf("unsupported dialect %q (expected one of %s)", c.Dialect, strings.Join(Dialects, ", "))
+	}
+	for _, tag := range c.Tags {
+		if _, _, err := ParseTag(tag); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ParseTag splits a struct tag setting like "json:camel" into the tag name and its naming style.
+func ParseTag(spec string) (name, style string, err error) {
+	name, style, hasStyle := strings.Cut(strings.TrimSpace(spec), ":")
+	defaultStyle, ok := TagDefaults[name]
+	if !ok {
+		names := make([]string, 0, len(TagDefaults))
+		for n := range TagDefaults {
+			names = append(names, n)
+		}
+		slices.Sort(names)
+		return "", "", fmt.Errorf("unsupported struct tag %q (expected one of %s)", name, strings.Join(names, ", "))
+	}
+	if !hasStyle {
+		return name, defaultStyle, nil
+	}
+	if defaultStyle == "" {
+		return "", "", fmt.Errorf("struct tag %q takes no naming style", name)
+	}
+	if !slices.Contains(TagStyles, style) {
+		return "", "", fmt.Errorf("unsupported naming style %q for struct tag %s (expected one of %s)", style, name, strings.Join(TagStyles, ", "))
+	}
+	return name, style, nil
+}
@@ -0,0 +1,356 @@
+package diff
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+)
+
+// Kind tells whether a line is shared by both sides, or only present in one of them.
+type Kind int
+
+const (
+	Equal Kind = iota
+	Delete
+	Insert
+)
+
+// Edit is a single line of a line-based diff.
+type Edit struct {
+	Kind Kind
+	Text string
+}
+
+// SplitLines splits text into lines without their trailing newline.
+func SplitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	lines := strings.Split(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// Lines computes the shortest edit script turning a into b using Myers' algorithm.
+func Lines(a, b []string) []Edit {
+	n, m := len(a), len(b)
+	limit := n + m
+	offset := limit + 1
+	v := make([]int, 2*limit+3)
+	var trace [][]int
+
+search:
+	for d := 0; d <= limit; d++ {
+		snapshot := make([]int, len(v))
+		copy(snapshot, v)
+		trace = append(trace, snapshot)
+
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				break search
+			}
+		}
+	}
+
+	// Walk the trace backwards to recover the edits.
+	var edits []Edit
+	x, y := n, m
+	for d := len(trace) - 1; d >= 0; d-- {
+		v := trace[d]
+		k := x - y
+
+		var prevK int
+		if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := v[offset+prevK]
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			edits = append(edits, Edit{Kind: Equal, Text: a[x-1]})
+			x--
+			y--
+		}
+		if d > 0 {
+			if x == prevX {
+				edits = append(edits, Edit{Kind: Insert, Text: b[y-1]})
+			} else {
+				edits = append(edits, Edit{Kind: Delete, Text: a[x-1]})
+			}
+		}
+		x, y = prevX, prevY
+	}
+
+	for i, j := 0, len(edits)-1; i < j; i, j = i+1, j-1 {
+		edits[i], edits[j] = edits[j], edits[i]
+	}
+	return edits
+}
+
+// Unified renders a unified diff between before and after with three lines of context.
+// It returns an empty string when both sides are identical.
+func Unified(fromName, toName, before, after string) string {
+	edits := Lines(unifiedLines(before), unifiedLines(after))
+
+	changed := false
+	for _, e := range edits {
+		if e.Kind != Equal {
+			changed = true
+			break
+		}
+	}
+	if !changed {
+		return ""
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n+++ %s\n", fromName, toName)
+	for _, h := range hunks(edits, 3) {
+		h.write(&sb)
+	}
+	return sb.String()
+}
+
+// noNewline follows a last line that does not end with a newline, the way diff marks it.
+const noNewline = "\n\\ No newline at end of file"
+
+// unifiedLines splits text into lines for Unified, where a last line missing its newline
+// differs from the same line with one.
+func unifiedLines(text string) []string {
+	lines := SplitLines(text)
+	if text != "" && !strings.HasSuffix(text, "\n") {
+		lines[len(lines)-1] += noNewline
+	}
+	return lines
+}
+
+type hunk struct {
+	fromLine, fromCount int
+	toLine, toCount     int
+	edits               []Edit
+}
+
+func (h hunk) write(sb *strings.Builder) {
+	fromLine, toLine := h.fromLine, h.toLine
+	// An empty range is addressed by the line that precedes it.
+	if h.fromCount > 0 {
+		fromLine++
+	}
+	if h.toCount > 0 {
+		toLine++
+	}
+	fmt.Fprintf(sb, "@@ -%d,%d +%d,%d @@\n", fromLine, h.fromCount, toLine, h.toCount)
+	for _, e := range h.edits {
+		switch e.Kind {
+		case Equal:
+			sb.WriteString(" ")
+		case Delete:
+			sb.WriteString("-")
+		case Insert:
+			sb.WriteString("+")
+		}
+		sb.WriteString(e.Text)
+		sb.WriteString("\n")
+	}
+}
+
+// hunks groups edits into hunks, keeping context unchanged lines around every change.
+func hunks(edits []Edit, context int) []hunk {
+	var result []hunk
+
+	// Line positions (0-based) in both files at the start of each edit.
+	fromPos := make([]int, len(edits)+1)
+	toPos := make([]int, len(edits)+1)
+	for i, e := range edits {
+		fromPos[i+1], toPos[i+1] = fromPos[i], toPos[i]
+		if e.Kind != Insert {
+			fromPos[i+1]++
+		}
+		if e.Kind != Delete {
+			toPos[i+1]++
+		}
+	}
+
+	i := 0
+	for i < len(edits) {
+		if edits[i].Kind == Equal {
+			i++
+			continue
+		}
+
+		start := max(i-context, 0)
+		end := i
+		for end < len(edits) {
+			if edits[end].Kind != Equal {
+				end++
+				continue
+			}
+			// Look ahead: keep going if another change is close enough to share context.
+			next := end
+			for next < len(edits) && edits[next].Kind == Equal {
+				next++
+			}
+			if next < len(edits) && next-end <= 2*context {
+				end = next
+				continue
+			}
+			end = min(end+context, len(edits))
+			break
+		}
+
+		h := hunk{
+			fromLine: fromPos[start],
+			toLine:   toPos[start],
+			edits:    edits[start:end],
+		}
+		h.fromCount = fromPos[end] - fromPos[start]
+		h.toCount = toPos[end] - toPos[start]
+		result = append(result, h)
+		i = end
+	}
+	return result
+}
+
+// Merge combines the changes existing and generated each made to base, the content the file
+// was generated with, the way git merges branches. A hunk changed on one side only takes that
+// change, while hunks both sides changed differently are wrapped in git-style conflict markers.
+// It reports whether any conflict markers were written.
+func Merge(base, existing, generated string) (string, bool) {
+	return merge(SplitLines(base), SplitLines(existing), SplitLines(generated), false)
+}
+
+// MergeWithoutBase combines two versions of a file that have no known common base. Nothing
+// tells which side changed a line, so the lines both share are kept and every hunk where they
+// differ is wrapped in conflict markers. It reports whether any conflict markers were written.
+func MergeWithoutBase(existing, generated string) (string, bool) {
+	// The lines both sides share stand in for the base
+	var common []string
+	for _, e := range Lines(SplitLines(existing), SplitLines(generated)) {
+		if e.Kind == Equal {
+			common = append(common, e.Text)
+		}
+	}
+	return merge(common, SplitLines(existing), SplitLines(generated), true)
+}
+
+// change replaces the lines [start, end) of a base with lines.
+type change struct {
+	start, end int
+	lines      []string
+}
+
+// changes lists the hunks turning base into side.
+func changes(base, side []string) []change {
+	var result []change
+	at := 0
+	edits := Lines(base, side)
+	for i := 0; i < len(edits); {
+		if edits[i].Kind == Equal {
+			at++
+			i++
+			continue
+		}
+		c := change{start: at, end: at}
+		for ; i < len(edits) && edits[i].Kind != Equal; i++ {
+			if edits[i].Kind == Delete {
+				c.end++
+			} else {
+				c.lines = append(c.lines, edits[i].Text)
+			}
+		}
+		at = c.end
+		result = append(result, c)
+	}
+	return result
+}
+
+// merge walks base, taking the changes of ours and theirs. Changes that overlap or touch are
+// grouped, and a group changed on both sides is a conflict unless both made the same change.
+// With strict, a group changed on one side is a conflict too.
+func merge(base, ours, theirs []string, strict bool) (string, bool) {
+	oc, tc := changes(base, ours), changes(base, theirs)
+
+	var sb strings.Builder
+	conflicts := false
+	at := 0
+	for len(oc) > 0 || len(tc) > 0 {
+		start := math.MaxInt
+		if len(oc) > 0 {
+			start = oc[0].start
+		}
+		if len(tc) > 0 {
+			start = min(start, tc[0].start)
+		}
+		end := start
+		var og, tg []change
+		for {
+			if len(oc) > 0 && oc[0].start <= end {
+				end = max(end, oc[0].end)
+				og, oc = append(og, oc[0]), oc[1:]
+				continue
+			}
+			if len(tc) > 0 && tc[0].start <= end {
+				end = max(end, tc[0].end)
+				tg, tc = append(tg, tc[0]), tc[1:]
+				continue
+			}
+			break
+		}
+		writeLines(&sb, base[at:start])
+		at = end
+
+		ourLines, theirLines := apply(base, start, end, og), apply(base, start, end, tg)
+		switch {
+		case !strict && len(tg) == 0:
+			writeLines(&sb, ourLines)
+		case !strict && len(og) == 0:
+			writeLines(&sb, theirLines)
+		case slices.Equal(ourLines, theirLines):
+			writeLines(&sb, ourLines)
+		default:
+			conflicts = true
+			sb.WriteString("<<<<<<< existing\n")
+			writeLines(&sb, ourLines)
+			sb.WriteString("=======\n")
+			writeLines(&sb, theirLines)
+			sb.WriteString(">>>>>>> generated\n")
+		}
+	}
+	writeLines(&sb, base[at:])
+	return sb.String(), conflicts
+}
+
+// apply returns the lines [start, end) of base with changes made to them.
+func apply(base []string, start, end int, changes []change) []string {
+	var lines []string
+	at := start
+	for _, c := range changes {
+		lines = append(lines, base[at:c.start]...)
+		lines = append(lines, c.lines...)
+		at = c.end
+	}
+	return append(lines, base[at:end]...)
+}
+
+func writeLines(sb *strings.Builder, lines []string) {
+	for _, line := range lines {
+		sb.WriteString(line + "\n")
+	}
+}
@@ -0,0 +1,255 @@
+package diff
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestUnified(t *testing.T) {
+	tests := []struct {
+		name          string
+		before, after string
+		want          string
+	}{
+		{
+			name: "both empty",
+		},
+		{
+			name:   "identical",
+			before: "a\nb\nc\n",
+			after:  "a\nb\nc\n",
+		},
+		{
+			name:  "created",
+			after: "a\nb\n",
+			want: `--- a/f
++++ b/f
+@@ -0,0 +1,2 @@
++a
++b
+`,
+		},
+		{
+			name:   "emptied",
+			before: "a\nb\n",
+			want: `--- a/f
++++ b/f
+@@ -1,2 +0,0 @@
+-a
+-b
+`,
+		},
+		{
+			name:   "insert only",
+			before: "1\n2\n3\n4\n5\n6\n7\n8\n",
+			after:  "1\n2\n3\n4\nnew\n5\n6\n7\n8\n",
+			want: `--- a/f
++++ b/f
+@@ -2,6 +2,7 @@
+ 2
+ 3
+ 4
++new
+ 5
+ 6
+ 7
+`,
+		},
+		{
+			name:   "delete only",
+			before: "1\n2\n3\n4\n5\n6\n7\n8\n",
+			after:  "1\n2\n3\n5\n6\n7\n8\n",
+			want: `--- a/f
++++ b/f
+@@ -1,7 +1,6 @@
+ 1
+ 2
+ 3
+-4
+ 5
+ 6
+ 7
+`,
+		},
+		{
+			name:   "separate hunks",
+			before: "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n",
+			after:  "one\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\ntwelve\n",
+			want: `--- a/f
++++ b/f
+@@ -1,4 +1,4 @@
+-1
++one
+ 2
+ 3
+ 4
+@@ -9,4 +9,4 @@
+ 9
+ 10
+ 11
+-12
++twelve
+`,
+		},
+		{
+			name:   "newline removed",
+			before: "a\nb\n",
+			after:  "a\nb",
+			want: `--- a/f
++++ b/f
+@@ -1,2 +1,2 @@
+ a
+-b
++b
+\ No newline at end of file
+`,
+		},
+		{
+			name:   "newline added",
+			before: "a\nb",
+			after:  "a\nb\n",
+			want: `--- a/f
++++ b/f
+@@ -1,2 +1,2 @@
+ a
+-b
+\ No newline at end of file
++b
+`,
+		},
+		{
+			name:   "no newline on either side",
+			before: "a\nb",
+			after:  "a\nc",
+			want: `--- a/f
++++ b/f
+@@ -1,2 +1,2 @@
+ a
+-b
+\ No newline at end of file
++c
+\ No newline at end of file
+`,
+		},
+		{
+			name:   "identical without newline",
+			before: "a\nb",
+			after:  "a\nb",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Unified("a/f", "b/f", tt.before, tt.after); got != tt.want {
+				t.Errorf("Unified() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLines(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want string
+	}{
+		{name: "empty"},
+		{name: "identical", a: "a b c", b: "a b c", want: "=a =b =c"},
+		{name: "insert only", a: "a c", b: "a b c d", want: "=a +b =c +d"},
+		{name: "delete only", a: "a b c d", b: "b d", want: "-a =b -c =d"},
+		{name: "replace", a: "a b c", b: "a x c", want: "=a -b +x =c"},
+		{name: "from nothing", b: "a b", want: "+a +b"},
+		{name: "to nothing", a: "a b", want: "-a -b"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, e := range Lines(strings.Fields(tt.a), strings.Fields(tt.b)) {
+				got = append(got, string("=-+"[e.Kind])+e.Text)
+			}
+			if s := strings.Join(got, " "); s != tt.want {
+				t.Errorf("Lines() = %q, want %q", s, tt.want)
+			}
+		})
+	}
+}
+
+func TestMerge(t *testing.T) {
+	tests := []struct {
+		name                      string
+		base, existing, generated string
+		want                      string
+		conflicts                 bool
+	}{
+		{
+			name:      "identical",
+			base:      "a\nb\n",
+			existing:  "a\nb\n",
+			generated: "a\nb\n",
+			want:      "a\nb\n",
+		},
+		{
+			name:      "changes of both sides kept",
+			base:      "a\nb\nc\nd\n",
+			existing:  "a\nmine\nb\nc\nd\n",
+			generated: "a\nb\nc\ntheirs\n",
+			want:      "a\nmine\nb\nc\ntheirs\n",
+		},
+		{
+			name:      "line removed on one side",
+			base:      "a\nb\nc\nd\n",
+			existing:  "a\nc\nd\n",
+			generated: "a\nb\nc\nd\ne\n",
+			want:      "a\nc\nd\ne\n",
+		},
+		{
+			name:      "same change on both sides",
+			base:      "a\nb\nc\n",
+			existing:  "a\nsame\nc\n",
+			generated: "a\nsame\nc\n",
+			want:      "a\nsame\nc\n",
+		},
+		{
+			name:      "changed on both sides",
+			base:      "a\nb\nc\n",
+			existing:  "a\nmine\nc\n",
+			generated: "a\ntheirs\nc\n",
+			want:      "a\n<<<<<<< existing\nmine\n=======\ntheirs\n>>>>>>> generated\nc\n",
+			conflicts: true,
+		},
+		{
+			name:      "changed on one side, removed on the other",
+			base:      "a\nb\nc\n",
+			existing:  "a\nmine\nc\n",
+			generated: "a\nc\n",
+			want:      "a\n<<<<<<< existing\nmine\n=======\n>>>>>>> generated\nc\n",
+			conflicts: true,
+		},
+		{
+			name:      "added at the same place",
+			base:      "a\n",
+			existing:  "a\nmine\n",
+			generated: "a\ntheirs\n",
+			want:      "a\n<<<<<<< existing\nmine\n=======\ntheirs\n>>>>>>> generated\n",
+			conflicts: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, conflicts := Merge(tt.base, tt.existing, tt.generated)
+			if got != tt.want || conflicts != tt.conflicts {
+				t.Errorf("Merge() = %q, %v, want %q, %v", got, conflicts, tt.want, tt.conflicts)
+			}
+		})
+	}
+}
+
+func TestMergeWithoutBase(t *testing.T) {
+	got, conflicts := MergeWithoutBase("a\nmine\nb\nc\n", "a\nb\ntheirs\nc\n")
+	want := "a\n<<<<<<< existing\nmine\n=======\n>>>>>>> generated\nb\n<<<<<<< existing\n=======\ntheirs\n>>>>>>> generated\nc\n"
+	if got != want || !conflicts {
+		t.Errorf("MergeWithoutBase() = %q, %v, want %q, true", got, conflicts, want)
+	}
+	if got, conflicts := MergeWithoutBase("a\n", "a\n"); got != "a\n" || conflicts {
+		t.Errorf("MergeWithoutBase() of identical files = %q, %v", got, conflicts)
+	}
+}
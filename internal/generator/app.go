@@ -1,10 +1,5 @@
 package generator
 
-func GenerateApp(moduleName string) error {
-	data := struct {
-		ModuleName string
-	}{
-		ModuleName: moduleName,
-	}
-	return CreateFileFromTemplate("internal/app/app.go", AppTemplate, data)
+func GenerateApp(ctx *ProjectContext) error {
+	return CreateFileFromLayout("", "app", ctx)
 }
@@ -0,0 +1,185 @@
+package generator
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/theHamdiz/gun/internal/diff"
+	"github.com/theHamdiz/it"
+)
+
+// ConflictPolicy decides what happens when a generator is about to overwrite
+// an existing file whose content differs from the generated one.
+type ConflictPolicy string
+
+const (
+	ConflictSkip   ConflictPolicy = "skip"
+	ConflictForce  ConflictPolicy = "force"
+	ConflictPrompt ConflictPolicy = "prompt"
+	ConflictBackup ConflictPolicy = "backup"
+	ConflictMerge  ConflictPolicy = "merge"
+)
+
+// ConflictPolicies lists every supported policy, in the order they are documented.
+var ConflictPolicies = []ConflictPolicy{ConflictSkip, ConflictForce, ConflictPrompt, ConflictBackup, ConflictMerge}
+
+// ErrAborted is returned when the user quits an interactive conflict prompt.
+var ErrAborted = errors.New("aborted by user")
+
+// ParseConflictPolicy validates a policy given on the command line.
+func ParseConflictPolicy(s string) (ConflictPolicy, error) {
+	for _, p := range ConflictPolicies {
+		if string(p) == strings.ToLower(s) {
+			return p, nil
+		}
+	}
+	names := make([]string, len(ConflictPolicies))
+	for i, p := range ConflictPolicies {
+		names[i] = string(p)
+	}
+	return "", fmt.Errorf("unknown conflict policy %q (expected one of %s)", s, strings.Join(names, ", "))
+}
+
+// SetConflictPolicy sets the policy used for every following write.
+func SetConflictPolicy(policy ConflictPolicy) {
+	plan.mu.Lock()
+	defer plan.mu.Unlock()
+	plan.policy = policy
+}
+
+func conflictPolicy() ConflictPolicy {
+	plan.mu.Lock()
+	defer plan.mu.Unlock()
+	if plan.policy == "" {
+		return ConflictPrompt
+	}
+	return plan.policy
+}
+
+// Prompts are read from stdin and serialized, since project creation writes concurrently.
+var (
+	promptMu     sync.Mutex
+	promptIn     *bufio.Reader = bufio.NewReader(os.Stdin)
+	promptOut    io.Writer     = os.Stdout
+	promptPolicy ConflictPolicy
+)
+
+// resolution is the outcome of a conflict: what to record and what to write.
+type resolution struct {
+	action  Action
+	content []byte
+	backup  bool
+}
+
+// resolveConflict applies the active policy to a file that exists with different content.
+func resolveConflict(path string, before, after []byte) (resolution, error) {
+	policy := conflictPolicy()
+	if policy == ConflictPrompt {
+		if IsDryRun() {
+			return resolution{action: ActionConflict, content: after}, nil
+		}
+		var err error
+		policy, err = prompt(path, before, after)
+		if err != nil {
+			return resolution{}, err
+		}
+	}
+
+	switch policy {
+	case ConflictSkip:
+		return resolution{action: ActionSkip, content: before}, nil
+	case ConflictBackup:
+		return resolution{action: ActionModify, content: after, backup: true}, nil
+	case ConflictMerge:
+		merged, err := merge(path, before, after)
+		if err != nil {
+			return resolution{}, err
+		}
+		return resolution{action: ActionMerge, content: merged}, nil
+	default:
+		return resolution{action: ActionModify, content: after}, nil
+	}
+}
+
+// merge combines the edits made to a file since it was generated with the generated content,
+// starting from the content recorded in the manifest. Merged Go files are formatted, unless
+// they hold conflict markers.
+func merge(path string, before, after []byte) ([]byte, error) {
+	m, err := LoadManifest()
+	if err != nil {
+		return nil, err
+	}
+	var merged string
+	var conflicts bool
+	if base, ok := m.generated(path); ok {
+		merged, conflicts = diff.Merge(string(base), string(before), string(after))
+	} else {
+		it.Warnf("%s was not generated by gun, every difference with the generated file is a conflict", filepath.ToSlash(path))
+		merged, conflicts = diff.MergeWithoutBase(string(before), string(after))
+	}
+	if conflicts {
+		it.Warnf("%s was merged with conflicts, resolve the conflict markers by hand", path)
+		return []byte(merged), nil
+	}
+	if filepath.Ext(path) != ".go" {
+		return []byte(merged), nil
+	}
+	formatted, err := FormatSource(path, []byte(merged))
+	if err != nil {
+		it.Warnf("%s was merged into invalid Go, fix it by hand: %v", path, err)
+		return []byte(merged), nil
+	}
+	return formatted, nil
+}
+
+// prompt shows the diff for a conflicting file and asks the user what to do with it.
+func prompt(path string, before, after []byte) (ConflictPolicy, error) {
+	promptMu.Lock()
+	defer promptMu.Unlock()
+
+	// An earlier answer may apply to all remaining files.
+	if promptPolicy != "" {
+		return promptPolicy, nil
+	}
+
+	slashed := filepath.ToSlash(path)
+	_, _ = fmt.Fprintf(promptOut, "\n%s already exists and differs from the generated file:\n\n", slashed)
+	_, _ = fmt.Fprint(promptOut, diff.Unified("a/"+slashed, "b/"+slashed, string(before), string(after)))
+
+	for {
+		_, _ = fmt.Fprintf(promptOut, "Overwrite %s? [y]es, [n]o, [a]ll, [s]kip all, [b]ackup, [m]erge, [q]uit: ", slashed)
+		answer, err := promptIn.ReadString('\n')
+		if err != nil && answer == "" {
+			if errors.Is(err, io.EOF) {
+				it.Warnf("No answer for %s, skipping it", slashed)
+				return ConflictSkip, nil
+			}
+			return "", err
+		}
+
+		switch strings.ToLower(strings.TrimSpace(answer)) {
+		case "y", "yes":
+			return ConflictForce, nil
+		case "n", "no":
+			return ConflictSkip, nil
+		case "a", "all":
+			promptPolicy = ConflictForce
+			return ConflictForce, nil
+		case "s", "skip all":
+			promptPolicy = ConflictSkip
+			return ConflictSkip, nil
+		case "b", "backup":
+			return ConflictBackup, nil
+		case "m", "merge":
+			return ConflictMerge, nil
+		case "q", "quit":
+			return "", ErrAborted
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+)
+
+const generatedHandler = `package handlers
+
+func List() string {
+	return "list"
+}
+
+func Show() string {
+	return "show"
+}
+`
+
+func TestMergePolicy(t *testing.T) {
+	inTempDir(t)
+	SetConflictPolicy(ConflictMerge)
+	t.Cleanup(func() { SetConflictPolicy("") })
+	generate := func(content string) {
+		t.Helper()
+		if err := track("handler", "post", func() error { return WriteFile("post.go", []byte(content)) }); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	generate(generatedHandler)
+	// The user edits List without formatting it, the template changes Show and adds Create
+	writeTestFile(t, "post.go", strings.Replace(generatedHandler, "\treturn \"list\"", "    return \"my list\"", 1))
+	generate(strings.Replace(generatedHandler, `return "show"`, `return "shown"`, 1) + "\nfunc Create() string {\n\treturn \"create\"\n}\n")
+
+	want := strings.Replace(strings.Replace(generatedHandler, `return "list"`, `return "my list"`, 1), `return "show"`, `return "shown"`, 1) +
+		"\nfunc Create() string {\n\treturn \"create\"\n}\n"
+	if got := readTestFile(t, "post.go"); got != want {
+		t.Errorf("merged post.go =\n%s\nwant\n%s", got, want)
+	}
+
+	// Both sides change List
+	writeTestFile(t, "post.go", strings.Replace(generatedHandler, `return "list"`, `return "mine"`, 1))
+	generate(strings.Replace(generatedHandler, `return "list"`, `return "theirs"`, 1))
+	got := readTestFile(t, "post.go")
+	if !strings.Contains(got, "<<<<<<< existing\n\treturn \"mine\"\n=======\n\treturn \"theirs\"\n>>>>>>> generated\n") {
+		t.Errorf("merged post.go has no conflict on List:\n%s", got)
+	}
+}
+
+func TestMergePolicyWithoutBase(t *testing.T) {
+	inTempDir(t)
+	SetConflictPolicy(ConflictMerge)
+	t.Cleanup(func() { SetConflictPolicy("") })
+
+	// A file gun did not generate: lines only one side has are conflicts too
+	writeTestFile(t, "notes.txt", "a\nmine\nb\n")
+	if err := WriteFile("notes.txt", []byte("a\nb\ntheirs\n")); err != nil {
+		t.Fatal(err)
+	}
+	want := "a\n<<<<<<< existing\nmine\n=======\n>>>>>>> generated\nb\n<<<<<<< existing\n=======\ntheirs\n>>>>>>> generated\n"
+	if got := readTestFile(t, "notes.txt"); got != want {
+		t.Errorf("merged notes.txt = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,77 @@
+package generator
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/theHamdiz/gun/internal/config"
+	"golang.org/x/mod/modfile"
+)
+
+// ProjectContext describes the project the generators run in.
+// It is embedded in the data of every template, so `{{ .ModuleName }}` and friends always resolve.
+type ProjectContext struct {
+	// Root is the absolute path of the directory holding go.mod.
+	Root string `json:"root"`
+	// Name is the name of the project directory.
+	Name       string `json:"name"`
+	ModuleName string `json:"module_name"`
+	GoVersion  string `json:"go_version"`
+	// Config holds the choices made when the project was created, with command line overrides applied.
+	Config config.Config `json:"config"`
+}
+
+// FindProject walks upward from dir until it finds a go.mod, and reads the project context from it.
+func FindProject(dir string) (*ProjectContext, error) {
+	start, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	for dir := start; ; dir = filepath.Dir(dir) {
+		goModPath := filepath.Join(dir, "go.mod")
+		data, err := os.ReadFile(goModPath)
+		if err == nil {
+			return parseProject(dir, goModPath, data)
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+		if filepath.Dir(dir) == dir {
+			return nil, fmt.Errorf("no go.mod found in %s or any parent directory, run gun inside a Go project", start)
+		}
+	}
+}
+
+func parseProject(root, goModPath string, data []byte) (*ProjectContext, error) {
+	file, err := modfile.ParseLax(goModPath, data, nil)
+	if err != nil {
+		return nil, err
+	}
+	if file.Module == nil || file.Module.Mod.Path == "" {
+		return nil, fmt.Errorf("%s does not declare a module path", goModPath)
+	}
+
+	ctx := &ProjectContext{
+		Root:       root,
+		Name:       filepath.Base(root),
+		ModuleName: file.Module.Mod.Path,
+	}
+	if file.Go != nil {
+		ctx.GoVersion = file.Go.Version
+	}
+
+	ctx.Config, err = config.Load(root)
+	if err != nil {
+		return nil, err
+	}
+	// go.mod stays the source of truth for the module path.
+	ctx.Config.ModuleName = ctx.ModuleName
+	if ctx.Config.Name == "" {
+		ctx.Config.Name = ctx.Name
+	}
+	return ctx, nil
+}
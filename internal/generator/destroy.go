@@ -0,0 +1,162 @@
+package generator
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/theHamdiz/it"
+)
+
+// Destroy reverses a generator: it removes exactly the files recorded for kind and name in the manifest.
+// Files edited since they were generated are handled according to the conflict policy.
+func Destroy(kind, name string) error {
+	m, err := LoadManifest()
+	if err != nil {
+		return err
+	}
+	res := m.Find(kind, name)
+	if res == nil {
+		return fmt.Errorf("no %s named %q was generated in this project (nothing recorded in %s)", kind, name, filepath.ToSlash(ManifestPath))
+	}
+
+	var kept, removed []GeneratedFile
+	for _, f := range res.Files {
+		path := filepath.FromSlash(f.Path)
+		content, err := os.ReadFile(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			it.Warnf("%s was already removed", f.Path)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		if hash(content) != f.SHA256 {
+			remove, err := confirmEditedRemoval(path, content)
+			if err != nil {
+				return err
+			}
+			if !remove {
+				kept = append(kept, f)
+				continue
+			}
+		}
+		removed = append(removed, f)
+	}
+
+	// The calls only go once every file is removed: a kept file still defines what they call.
+	// Taking them out first means nothing refers to the files about to be removed.
+	if len(kept) == 0 {
+		for _, reg := range res.Registrations {
+			if err := unregister(reg); err != nil {
+				return err
+			}
+		}
+	} else {
+		for _, reg := range res.Registrations {
+			it.Warnf("Kept the call to %s in %s, since %s was kept", reg.Call, reg.File, kept[0].Path)
+		}
+	}
+	for _, f := range removed {
+		if err := RemoveFile(filepath.FromSlash(f.Path)); err != nil {
+			return err
+		}
+	}
+
+	// Remove the directories the generator created, deepest first, as long as nothing else lives in them.
+	dirs := append([]string(nil), res.Dirs...)
+	sort.Slice(dirs, func(i, j int) bool {
+		return strings.Count(dirs[i], "/") > strings.Count(dirs[j], "/")
+	})
+	for _, dir := range dirs {
+		if err := RemoveEmptyDir(filepath.FromSlash(dir)); err != nil {
+			return err
+		}
+	}
+
+	if IsDryRun() {
+		return nil
+	}
+	if res.Kind == "model" {
+		if err := forgetModel(res.Name); err != nil {
+			return err
+		}
+	}
+	if len(kept) > 0 {
+		res.Files = kept
+	} else {
+		m.Remove(res.Kind, res.Name)
+	}
+	return m.Save()
+}
+
+// confirmEditedRemoval decides whether a file edited since it was generated may be deleted.
+func confirmEditedRemoval(path string, content []byte) (bool, error) {
+	slashed := filepath.ToSlash(path)
+
+	policy := conflictPolicy()
+	if policy == ConflictPrompt {
+		if IsDryRun() {
+			plan.record(Change{Path: path, Action: ActionConflict, Before: content, After: content})
+			return false, nil
+		}
+		var err error
+		policy, err = promptRemoval(slashed)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	switch policy {
+	case ConflictForce:
+		return true, nil
+	case ConflictBackup:
+		if err := write(Change{Path: path + ".orig", Action: ActionCreate, After: content, Backup: true}); err != nil {
+			return false, err
+		}
+		return true, nil
+	default:
+		it.Warnf("Kept %s, it was edited since it was generated", slashed)
+		return false, nil
+	}
+}
+
+func promptRemoval(path string) (ConflictPolicy, error) {
+	promptMu.Lock()
+	defer promptMu.Unlock()
+
+	if promptPolicy != "" {
+		return promptPolicy, nil
+	}
+
+	for {
+		_, _ = fmt.Fprintf(promptOut, "%s was edited since it was generated. Delete it anyway? [y]es, [n]o, [a]ll, [b]ackup, [q]uit: ", path)
+		answer, err := promptIn.ReadString('\n')
+		if err != nil && answer == "" {
+			if errors.Is(err, io.EOF) {
+				return ConflictSkip, nil
+			}
+			return "", err
+		}
+
+		switch strings.ToLower(strings.TrimSpace(answer)) {
+		case "y", "yes":
+			return ConflictForce, nil
+		case "n", "no":
+			return ConflictSkip, nil
+		case "a", "all":
+			promptPolicy = ConflictForce
+			return ConflictForce, nil
+		case "b", "backup":
+			return ConflictBackup, nil
+		case "q", "quit":
+			return "", ErrAborted
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package generator
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/theHamdiz/gun/internal/inflect"
+)
+
+// EnumType is the named type generated for an enum field, like PostStatus for the Status of a Post.
+type EnumType struct {
+	Name string
+	// Field is the field the type was generated for.
+	Field  string
+	Values []EnumValue
+}
+
+// EnumValue is one of the values of an EnumType, with the name of its constant.
+type EnumValue struct {
+	Const string
+	Value string
+}
+
+// parseEnum reads the values of an enum type, written enum(draft,published) with optionally
+// quoted values.
+func (f *Field) parseEnum(typ string) error {
+	inner, ok := strings.CutPrefix(typ, "enum(")
+	if !ok || !strings.HasSuffix(inner, ")") {
+		return fmt.Errorf("%q is not an enum, expected enum(value,...)", typ)
+	}
+	inner = strings.TrimSuffix(inner, ")")
+
+	var values []string
+	consts := map[string]string{}
+	for _, tok := range splitTopLevel(inner, 0, func(r byte) bool { return r == ',' }) {
+		value := strings.TrimSpace(tok.text)
+		if unquoted, err := strconv.Unquote(value); err == nil {
+			value = unquoted
+		}
+		if value == "" {
+			continue
+		}
+		if slices.Contains(values, value) {
+			return fmt.Errorf("enum of %s lists %q twice", f.Name, value)
+		}
+		name := inflect.Pascal(value)
+		// The constant is the type name followed by the value, so the value must add to it
+		if name == "" || !isIdentifier("X"+name) {
+			return fmt.Errorf("enum value %q does not make a constant name", value)
+		}
+		if other, taken := consts[name]; taken {
+			return fmt.Errorf("enum values %q and %q make the same constant name", other, value)
+		}
+		consts[name] = value
+		values = append(values, value)
+	}
+	if len(values) == 0 {
+		return fmt.Errorf("enum of %s has no values", f.Name)
+	}
+	f.Enum = values
+	// The type is named after the model once it is known, see enumTypes
+	f.Type = f.Name
+	return nil
+}
+
+// enumString renders the enum type of a field back into the --fields grammar.
+func enumString(values []string) string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = v
+		if strings.ContainsAny(v, " \t\n,:()\"'`") {
+			quoted[i] = strconv.Quote(v)
+		}
+	}
+	return "enum(" + strings.Join(quoted, ",") + ")"
+}
+
+// enumTypes names the types of the enum fields of a model after it, and returns them.
+func enumTypes(model string, fields []Field) []EnumType {
+	var enums []EnumType
+	for i, f := range fields {
+		if len(f.Enum) == 0 {
+			continue
+		}
+		enum := EnumType{Name: model + f.Name, Field: f.Name}
+		for _, v := range f.Enum {
+			enum.Values = append(enum.Values, EnumValue{Const: enumConst(enum.Name, v), Value: v})
+		}
+		fields[i].Type = enum.Name
+		if f.Pointer {
+			fields[i].Type = "*" + enum.Name
+		}
+		enums = append(enums, enum)
+	}
+	return enums
+}
+
+// enumConst names the constant of an enum value, like PostStatusDraft.
+func enumConst(typ, value string) string {
+	return typ + inflect.Pascal(value)
+}
+
+// sqlEnumValues reads the values of a MySQL ENUM column type, as ParseSQL keeps it: ENUM(a,b).
+func sqlEnumValues(typ string) ([]string, bool) {
+	open := strings.IndexByte(typ, '(')
+	if open < 0 || !strings.EqualFold(strings.TrimSpace(typ[:open]), "enum") || !strings.HasSuffix(typ, ")") {
+		return nil, false
+	}
+	var values []string
+	for _, v := range strings.Split(typ[open+1:len(typ)-1], ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values, len(values) > 0
+}
@@ -0,0 +1,479 @@
+package generator
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/theHamdiz/gun/internal/config"
+)
+
+// Field is a field of a model or resource, as given with --fields.
+type Field struct {
+	Name string
+	// Type is the Go type of the field, with optional fields turned into pointers.
+	Type string
+	// Imports are the packages the type refers to, like "time" for time.Time.
+	Imports []string
+
+	Pointer  bool
+	Slice    bool
+	Map      bool
+	Optional bool
+
+	Required bool
+	Unique   bool
+	Index    bool
+	Email    bool
+	URL      bool
+	// OneOf lists the values the field is limited to, for strings and numbers.
+	OneOf []string
+	// Enum lists the values of an enum field, whose type is named after its model, like PostStatus.
+	Enum []string
+	// Min and Max bound the value of numbers and the length of strings and slices.
+	Min string
+	Max string
+	// Default is the default value, unquoted.
+	Default    string
+	HasDefault bool
+	// TagValues override the struct tags derived from the name, like json=birthday.
+	TagValues map[string]string
+	// Relation is set for fields that link to another model, like Author:belongs_to:User.
+	Relation *Relation
+	// Tag is the rendered struct tag of model fields, without backquotes.
+	Tag string
+	// Managed is set for the fields the model options add, like CreatedAt, which the data
+	// layer maintains and forms leave out.
+	Managed bool
+}
+
+// builtinTypes are the predeclared types a field may use unqualified.
+var builtinTypes = map[string]bool{
+	"any": true, "bool": true, "byte": true, "complex64": true, "complex128": true, "error": true,
+	"float32": true, "float64": true, "int": true, "int8": true, "int16": true, "int32": true,
+	"int64": true, "rune": true, "string": true, "uint": true, "uint8": true, "uint16": true,
+	"uint32": true, "uint64": true, "uintptr": true,
+}
+
+// FieldError reports a malformed --fields value and points at the offending token.
+type FieldError struct {
+	Input  string
+	Offset int
+	Length int
+	Msg    string
+}
+
+func (e *FieldError) Error() string {
+	length := e.Length
+	if length < 1 {
+		length = 1
+	}
+	return fmt.Sprintf("invalid fields: %s\n    %s\n    %s%s", e.Msg, e.Input, strings.Repeat(" ", e.Offset), strings.Repeat("^", length))
+}
+
+// fieldToken is a piece of the input, with its position for error reporting.
+type fieldToken struct {
+	text   string
+	offset int
+}
+
+// ParseFields parses field definitions separated by spaces or commas:
+//
+//	Name:type[:modifier...]
+//
+// Types are Go types such as `string`, `*int`, `[]string`, `map[string]int` or `time.Time`,
+// or enums like `enum(draft,published)`. A trailing `?` makes a type optional, which turns it
+// into a pointer. The modifiers are
+// required, optional, unique, index, email, url, min=N, max=N, oneof=A|B|C and default=V, where
+// V may be quoted.
+// A struct tag name like json=V sets that tag of the field instead of deriving it from the name.
+// Relationships to other models are written Name:kind:Model, see Relation.
+func ParseFields(input string) ([]Field, error) {
+	var fields []Field
+	seen := map[string]bool{}
+	for _, def := range splitTopLevel(input, 0, func(r byte) bool { return r == ' ' || r == '\t' || r == '\n' || r == ',' }) {
+		field, err := parseField(input, def)
+		if err != nil {
+			return nil, err
+		}
+		if seen[field.Name] {
+			return nil, &FieldError{Input: input, Offset: def.offset, Length: len(field.Name), Msg: fmt.Sprintf("field %s is defined twice", field.Name)}
+		}
+		seen[field.Name] = true
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+func parseField(input string, def fieldToken) (Field, error) {
+	fail := func(tok fieldToken, format string, args ...any) (Field, error) {
+		return Field{}, &FieldError{Input: input, Offset: tok.offset, Length: len(tok.text), Msg: fmt.Sprintf(format, args...)}
+	}
+
+	parts := splitTopLevel(def.text, def.offset, func(r byte) bool { return r == ':' })
+	// Empty pieces are dropped, so a definition starting with a colon has lost its name
+	if len(parts) == 0 || parts[0].offset != def.offset {
+		return fail(def, "field %q has no name, expected Name:type", def.text)
+	}
+	name := parts[0]
+	if len(parts) < 2 {
+		return fail(def, "field %q has no type, expected Name:type", def.text)
+	}
+	if !token.IsIdentifier(name.text) {
+		return fail(name, "%q is not a valid field name", name.text)
+	}
+
+	field := Field{Name: name.text}
+	if slices.Contains(RelationKinds, parts[1].text) {
+		return parseRelation(field, parts, fail)
+	}
+	typ := parts[1]
+	if enum, optional := strings.CutSuffix(typ.text, "?"); strings.HasPrefix(enum, "enum(") {
+		if err := field.parseEnum(enum); err != nil {
+			return fail(typ, "%v", err)
+		}
+		if optional {
+			field.makeOptional()
+		}
+	} else if err := field.parseType(typ); err != nil {
+		return fail(typ, "%v", err)
+	}
+
+	for _, mod := range parts[2:] {
+		key, value, hasValue := strings.Cut(mod.text, "=")
+		if _, ok := config.TagDefaults[key]; ok {
+			if !hasValue {
+				return fail(mod, "%s expects the tag value, like %s=name", key, key)
+			}
+			if unquoted, err := strconv.Unquote(value); err == nil {
+				value = unquoted
+			}
+			if field.TagValues == nil {
+				field.TagValues = map[string]string{}
+			}
+			field.TagValues[key] = value
+			continue
+		}
+		switch key {
+		case "min", "max", "default", "oneof":
+			if !hasValue || value == "" {
+				return fail(mod, "%s expects a value, like %s=3", key, key)
+			}
+		case "required", "optional", "unique", "index", "email", "url":
+			if hasValue {
+				return fail(mod, "%s takes no value", key)
+			}
+		default:
+			return fail(mod, "unknown modifier %q (expected required, optional, unique, index, email, url, min=, max=, oneof=, default= or a struct tag like json=)", key)
+		}
+
+		switch key {
+		case "required":
+			field.Required = true
+		case "optional":
+			field.makeOptional()
+		case "unique":
+			field.Unique = true
+		case "index":
+			field.Index = true
+		case "email":
+			if strings.TrimPrefix(field.Type, "*") != "string" {
+				return fail(mod, "email only applies to string fields, %s is %s", field.Name, field.Type)
+			}
+			field.Email = true
+		case "url":
+			if strings.TrimPrefix(field.Type, "*") != "string" {
+				return fail(mod, "url only applies to string fields, %s is %s", field.Name, field.Type)
+			}
+			field.URL = true
+		case "oneof":
+			if unquoted, err := strconv.Unquote(value); err == nil {
+				value = unquoted
+			}
+			values := strings.Split(value, "|")
+			for i, v := range values {
+				if unquoted, err := strconv.Unquote(v); err == nil {
+					values[i] = unquoted
+				}
+			}
+			if err := field.setOneOf(values); err != nil {
+				return fail(mod, "%v", err)
+			}
+		case "min", "max":
+			if _, err := strconv.ParseFloat(value, 64); err != nil {
+				return fail(mod, "%s expects a number, got %q", key, value)
+			}
+			if key == "min" {
+				field.Min = value
+			} else {
+				field.Max = value
+			}
+		case "default":
+			if unquoted, err := strconv.Unquote(value); err == nil {
+				value = unquoted
+			}
+			field.Default, field.HasDefault = value, true
+		}
+	}
+
+	if field.Required && field.Optional {
+		return fail(def, "field %s cannot be both required and optional", field.Name)
+	}
+	if len(field.Enum) > 0 {
+		if field.Min != "" || field.Max != "" {
+			return fail(def, "min and max do not apply to enum field %s", field.Name)
+		}
+		if field.HasDefault && !slices.Contains(field.Enum, field.Default) {
+			return fail(def, "field %s has default=%s, which is not one of its values", field.Name, field.Default)
+		}
+	}
+	if field.Min != "" && field.Max != "" {
+		lo, _ := strconv.ParseFloat(field.Min, 64)
+		hi, _ := strconv.ParseFloat(field.Max, 64)
+		if lo > hi {
+			return fail(def, "field %s has min=%s greater than max=%s", field.Name, field.Min, field.Max)
+		}
+	}
+	return field, nil
+}
+
+// setOneOf limits the field to values, which must be of its type.
+func (f *Field) setOneOf(values []string) error {
+	kind := valueKind(*f)
+	if kind != "string" && kind != "int" && kind != "float" {
+		return fmt.Errorf("oneof only applies to string and number fields, %s is %s", f.Name, f.Type)
+	}
+	for _, v := range values {
+		if v == "" {
+			return fmt.Errorf("oneof of %s has an empty value", f.Name)
+		}
+		if kind == "int" {
+			if _, err := strconv.ParseInt(v, 10, 64); err != nil {
+				return fmt.Errorf("oneof of %s expects integers, got %q", f.Name, v)
+			}
+		} else if kind == "float" {
+			if _, err := strconv.ParseFloat(v, 64); err != nil {
+				return fmt.Errorf("oneof of %s expects numbers, got %q", f.Name, v)
+			}
+		}
+	}
+	f.OneOf = values
+	return nil
+}
+
+// parseType validates a Go type expression and records its shape and imports.
+func (f *Field) parseType(tok fieldToken) error {
+	typ, optional := strings.CutSuffix(tok.text, "?")
+	if typ == "" {
+		return fmt.Errorf("field %s has an empty type", f.Name)
+	}
+
+	expr, err := parser.ParseExpr(typ)
+	if err != nil {
+		return fmt.Errorf("%q is not a Go type", typ)
+	}
+	imports := map[string]bool{}
+	if err := checkType(expr, imports); err != nil {
+		return err
+	}
+
+	switch expr.(type) {
+	case *ast.StarExpr:
+		f.Pointer = true
+	case *ast.ArrayType:
+		f.Slice = true
+	case *ast.MapType:
+		f.Map = true
+	}
+	f.Type = typ
+	if optional {
+		f.makeOptional()
+	}
+	for path := range imports {
+		f.Imports = append(f.Imports, path)
+	}
+	sort.Strings(f.Imports)
+	return nil
+}
+
+// makeOptional marks the field optional. Types that cannot be nil already become pointers.
+func (f *Field) makeOptional() {
+	f.Optional = true
+	if !f.Pointer && !f.Slice && !f.Map {
+		f.Pointer = true
+		f.Type = "*" + f.Type
+	}
+}
+
+func checkType(expr ast.Expr, imports map[string]bool) error {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		// Exported names refer to other models of the same package
+		if !builtinTypes[t.Name] && !ast.IsExported(t.Name) {
+			return fmt.Errorf("unknown type %q", t.Name)
+		}
+		return nil
+	case *ast.SelectorExpr:
+		pkg, ok := t.X.(*ast.Ident)
+		if !ok {
+			return fmt.Errorf("%q is not a Go type", types.ExprString(expr))
+		}
+		path, ok := knownImports[pkg.Name]
+		if !ok {
+			return fmt.Errorf("unknown package %q in %s", pkg.Name, types.ExprString(expr))
+		}
+		imports[path] = true
+		return nil
+	case *ast.StarExpr:
+		return checkType(t.X, imports)
+	case *ast.ArrayType:
+		if t.Len != nil {
+			return fmt.Errorf("arrays are not supported, use a slice instead of %s", types.ExprString(expr))
+		}
+		return checkType(t.Elt, imports)
+	case *ast.MapType:
+		if err := checkType(t.Key, imports); err != nil {
+			return err
+		}
+		return checkType(t.Value, imports)
+	default:
+		return fmt.Errorf("%q is not a supported field type", types.ExprString(expr))
+	}
+}
+
+// splitTopLevel splits s at the bytes sep matches, except inside quotes and brackets.
+// Empty pieces are dropped. offset is the position of s in the whole input.
+func splitTopLevel(s string, offset int, sep func(byte) bool) []fieldToken {
+	var tokens []fieldToken
+	start, depth := 0, 0
+	var quote byte
+	flush := func(end int) {
+		if end > start {
+			tokens = append(tokens, fieldToken{text: s[start:end], offset: offset + start})
+		}
+		start = end + 1
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'' || c == '`':
+			quote = c
+		case c == '[' || c == '(' || c == '{':
+			depth++
+		case c == ']' || c == ')' || c == '}':
+			depth--
+		case depth == 0 && sep(c):
+			flush(i)
+		}
+	}
+	flush(len(s))
+	return tokens
+}
+
+// InputType is the HTML input type used for the field in forms. Enum fields use a select instead.
+func (f Field) InputType() string {
+	if len(f.Enum) > 0 {
+		return "select"
+	}
+	switch strings.TrimPrefix(f.Type, "*") {
+	case "bool":
+		return "checkbox"
+	case "int", "int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64", "float32", "float64":
+		return "number"
+	case "time.Time":
+		return "datetime-local"
+	}
+	if f.Email {
+		return "email"
+	}
+	if f.URL {
+		return "url"
+	}
+	return "text"
+}
+
+// InputAttrs are the HTML validation attributes matching the field's rules, with a leading space.
+func (f Field) InputAttrs() string {
+	var sb strings.Builder
+	if f.Required {
+		sb.WriteString(" required")
+	}
+	min, max := "min", "max"
+	if typ := f.InputType(); typ == "text" || typ == "email" || typ == "url" {
+		min, max = "minlength", "maxlength"
+	}
+	if f.Min != "" {
+		fmt.Fprintf(&sb, " %s=%q", min, f.Min)
+	}
+	if f.Max != "" {
+		fmt.Fprintf(&sb, " %s=%q", max, f.Max)
+	}
+	return sb.String()
+}
+
+// String renders the field back into the --fields grammar, which ParseFields reads again.
+func (f Field) String() string {
+	parts := []string{f.Name}
+	if r := f.Relation; r != nil {
+		parts = append(parts, r.Kind, r.Model)
+		if r.ForeignKey != "" {
+			parts = append(parts, "fk="+r.ForeignKey)
+		}
+		if r.Join != "" {
+			parts = append(parts, "through="+r.Join)
+		}
+	} else if len(f.Enum) > 0 {
+		parts = append(parts, enumString(f.Enum))
+	} else {
+		parts = append(parts, f.Type)
+	}
+
+	flags := []struct {
+		set  bool
+		name string
+	}{
+		{f.Required, "required"}, {f.Optional, "optional"}, {f.Unique, "unique"}, {f.Index, "index"}, {f.Email, "email"}, {f.URL, "url"},
+	}
+	for _, flag := range flags {
+		if flag.set {
+			parts = append(parts, flag.name)
+		}
+	}
+	if f.Min != "" {
+		parts = append(parts, "min="+f.Min)
+	}
+	if f.Max != "" {
+		parts = append(parts, "max="+f.Max)
+	}
+	if len(f.OneOf) > 0 {
+		oneOf := strings.Join(f.OneOf, "|")
+		if strings.ContainsAny(oneOf, " \t\n,:\"'`") {
+			oneOf = strconv.Quote(oneOf)
+		}
+		parts = append(parts, "oneof="+oneOf)
+	}
+	if f.HasDefault {
+		parts = append(parts, "default="+strconv.Quote(f.Default))
+	}
+	tags := make([]string, 0, len(f.TagValues))
+	for name := range f.TagValues {
+		tags = append(tags, name)
+	}
+	sort.Strings(tags)
+	for _, name := range tags {
+		parts = append(parts, name+"="+strconv.Quote(f.TagValues[name]))
+	}
+	return strings.Join(parts, ":")
+}
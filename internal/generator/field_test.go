@@ -0,0 +1,180 @@
+package generator
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseFields(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []Field
+	}{
+		{
+			name:  "empty",
+			input: "  ",
+		},
+		{
+			name:  "modifiers",
+			input: "Title:string:required:min=3:max=100 Slug:string:unique:index",
+			want: []Field{
+				{Name: "Title", Type: "string", Required: true, Min: "3", Max: "100"},
+				{Name: "Slug", Type: "string", Unique: true, Index: true},
+			},
+		},
+		{
+			name:  "separated by commas",
+			input: "Email:string:email,Site:string:url",
+			want: []Field{
+				{Name: "Email", Type: "string", Email: true},
+				{Name: "Site", Type: "string", URL: true},
+			},
+		},
+		{
+			name:  "oneof and quoted default",
+			input: `Role:string:oneof=admin|user:default="user" Level:int:oneof=1|2|3`,
+			want: []Field{
+				{Name: "Role", Type: "string", OneOf: []string{"admin", "user"}, Default: "user", HasDefault: true},
+				{Name: "Level", Type: "int", OneOf: []string{"1", "2", "3"}},
+			},
+		},
+		{
+			name:  "quoted values keep their separators",
+			input: `Greeting:string:default="hello, world":oneof="hello, world|hi"`,
+			want: []Field{
+				{Name: "Greeting", Type: "string", Default: "hello, world", HasDefault: true, OneOf: []string{"hello, world", "hi"}},
+			},
+		},
+		{
+			name:  "struct tags",
+			input: `BirthDate:time.Time:json=birthday:db="born_on"`,
+			want: []Field{
+				{Name: "BirthDate", Type: "time.Time", Imports: []string{"time"}, TagValues: map[string]string{"json": "birthday", "db": "born_on"}},
+			},
+		},
+		{
+			name:  "pointers, slices and maps",
+			input: "Age:int? Bio:string:optional Score:*float64 Tags:[]string? Meta:map[string]any IDs:[]uuid.UUID",
+			want: []Field{
+				{Name: "Age", Type: "*int", Pointer: true, Optional: true},
+				{Name: "Bio", Type: "*string", Pointer: true, Optional: true},
+				{Name: "Score", Type: "*float64", Pointer: true},
+				{Name: "Tags", Type: "[]string", Slice: true, Optional: true},
+				{Name: "Meta", Type: "map[string]any", Map: true},
+				{Name: "IDs", Type: "[]uuid.UUID", Imports: []string{"github.com/google/uuid"}, Slice: true},
+			},
+		},
+		{
+			name:  "other models",
+			input: "Address:Address Items:[]Item",
+			want: []Field{
+				{Name: "Address", Type: "Address"},
+				{Name: "Items", Type: "[]Item", Slice: true},
+			},
+		},
+		{
+			name:  "enums",
+			input: `Status:enum(draft,published):default=draft Mood:enum("so so",happy)?`,
+			want: []Field{
+				{Name: "Status", Type: "Status", Enum: []string{"draft", "published"}, Default: "draft", HasDefault: true},
+				{Name: "Mood", Type: "*Mood", Enum: []string{"so so", "happy"}, Pointer: true, Optional: true},
+			},
+		},
+		{
+			name:  "relations",
+			input: "Author:belongs_to:User:required:fk=WriterID Profile:has_one:Profile Comments:has_many:Comment Tags:many_to_many:Tag:through=PostTag",
+			want: []Field{
+				{Name: "Author", Type: "*User", Pointer: true, Required: true, Relation: &Relation{Kind: BelongsTo, Model: "User", ForeignKey: "WriterID"}},
+				{Name: "Profile", Type: "*Profile", Pointer: true, Relation: &Relation{Kind: HasOne, Model: "Profile"}},
+				{Name: "Comments", Type: "[]Comment", Slice: true, Relation: &Relation{Kind: HasMany, Model: "Comment"}},
+				{Name: "Tags", Type: "[]Tag", Slice: true, Relation: &Relation{Kind: ManyToMany, Model: "Tag", Join: "PostTag"}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseFields(tt.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseFields(%q) =\n%+v\nwant\n%+v", tt.input, got, tt.want)
+			}
+			// Fields render back into the grammar
+			again, err := ParseFields(fieldsString(got))
+			if err != nil || !reflect.DeepEqual(again, got) {
+				t.Errorf("ParseFields(%q) = %+v, %v, want the fields it was rendered from", fieldsString(got), again, err)
+			}
+		})
+	}
+}
+
+func fieldsString(fields []Field) string {
+	defs := make([]string, len(fields))
+	for i, f := range fields {
+		defs[i] = f.String()
+	}
+	return strings.Join(defs, " ")
+}
+
+func TestParseFieldsErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		// at marks the offending token under input
+		at  string
+		msg string
+	}{
+		{":", "^", `field ":" has no name`},
+		{"Title:string ::", "             ^^", `field "::" has no name`},
+		{":string", "^^^^^^^", `field ":string" has no name`},
+		{"Title", "^^^^^", `field "Title" has no type`},
+		{"Title:", "^^^^^^", `field "Title:" has no type`},
+		{"9lives:int", "^^^^^^", `"9lives" is not a valid field name`},
+		{"Title:string Title:int", "             ^^^^^", "field Title is defined twice"},
+		{"Title:strin", "      ^^^^^", `unknown type "strin"`},
+		{"Title:string?[]", "      ^^^^^^^^^", "is not a Go type"},
+		{"At:foo.Time", "   ^^^^^^^^", `unknown package "foo"`},
+		{"Grid:[3]int", "     ^^^^^^", "arrays are not supported"},
+		{"Fn:func()", "   ^^^^^^", "is not a supported field type"},
+		{"Title:string:loud", "             ^^^^", `unknown modifier "loud"`},
+		{"Title:string:min", "             ^^^", "min expects a value"},
+		{"Title:string:min=abc", "             ^^^^^^^", `min expects a number, got "abc"`},
+		{"Title:string:required=yes", "             ^^^^^^^^^^^^", "required takes no value"},
+		{"Title:string:json", "             ^^^^", "json expects the tag value"},
+		{"Age:int:email", "        ^^^^^", "email only applies to string fields"},
+		{"Age:int:url", "        ^^^", "url only applies to string fields"},
+		{"Age:int:oneof=1|two", "        ^^^^^^^^^^^", `oneof of Age expects integers, got "two"`},
+		{"Done:bool:oneof=true", "          ^^^^^^^^^^", "oneof only applies to string and number fields"},
+		{"Title:string:oneof=a||b", "             ^^^^^^^^^^", "oneof of Title has an empty value"},
+		{"Title:string:required:optional", "^^^^^^^^^^^^^^^^^^^^^^^^^^^^^^", "cannot be both required and optional"},
+		{"Age:int:min=10:max=1", "^^^^^^^^^^^^^^^^^^^^", "min=10 greater than max=1"},
+		{"Status:enum()", "       ^^^^^^", "enum of Status has no values"},
+		{"Status:enum(a,a)", "       ^^^^^^^^^", `enum of Status lists "a" twice`},
+		{"Status:enum(a-b,a_b)", "       ^^^^^^^^^^^^^", `enum values "a-b" and "a_b" make the same constant name`},
+		{"Status:enum(ok,!)", "       ^^^^^^^^^^", `enum value "!" does not make a constant name`},
+		{"Status:enum(a,b):min=1", "^^^^^^^^^^^^^^^^^^^^^^", "min and max do not apply to enum field Status"},
+		{"Status:enum(a,b):default=c", "^^^^^^^^^^^^^^^^^^^^^^^^^^", "default=c, which is not one of its values"},
+		{"Author:belongs_to", "       ^^^^^^^^^^", "belongs_to needs the related model"},
+		{"Author:belongs_to:user", "                  ^^^^", `"user" is not a model name`},
+		{"Author:belongs_to:User:unique", "                       ^^^^^^", `unsupported modifier "unique" for belongs_to`},
+		{"Comments:has_many:Comment:required", "                          ^^^^^^^^", `unsupported modifier "required" for has_many`},
+		{"Tags:has_many:Tag:through=PostTag", "                  ^^^^^^^^^^^^^^^", `unsupported modifier "through=PostTag" for has_many`},
+		{"Author:belongs_to:User:required:optional", "^^^^^^", "cannot be both required and optional"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			_, err := ParseFields(tt.input)
+			var fieldErr *FieldError
+			if !errors.As(err, &fieldErr) {
+				t.Fatalf("ParseFields(%q) = %v, want a FieldError", tt.input, err)
+			}
+			at := strings.Repeat(" ", fieldErr.Offset) + strings.Repeat("^", max(fieldErr.Length, 1))
+			if at != tt.at || !strings.Contains(fieldErr.Msg, tt.msg) {
+				t.Errorf("ParseFields(%q) points at\n%s\n%s\nwith %q, want\n%s\n%s\nwith %q", tt.input, tt.input, at, fieldErr.Msg, tt.input, tt.at, tt.msg)
+			}
+		})
+	}
+}
@@ -0,0 +1,465 @@
+package generator
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"maps"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// TemplateError reports a template that failed to execute or rendered code that does not parse.
+type TemplateError struct {
+	Template    string
+	Destination string
+	Line        int
+	Source      string
+	Data        any
+	Err         error
+}
+
+func (e *TemplateError) Error() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "template %q for %s: %v", e.Template, e.Destination, e.Err)
+	if e.Line > 0 {
+		fmt.Fprintf(&sb, "\n    %d | %s", e.Line, e.Source)
+	}
+	fmt.Fprintf(&sb, "\n  data: %+v", e.Data)
+	return sb.String()
+}
+
+func (e *TemplateError) Unwrap() error {
+	return e.Err
+}
+
+// knownImports resolves package names that templates are allowed to use without importing them.
+var knownImports = map[string]string{
+	"atomic":   "sync/atomic",
+	"bcrypt":   "golang.org/x/crypto/bcrypt",
+	"context":  "context",
+	"driver":   "database/sql/driver",
+	"embed":    "embed",
+	"errors":   "errors",
+	"fiber":    "github.com/gofiber/fiber/v2",
+	"filepath": "path/filepath",
+	"fmt":      "fmt",
+	"fs":       "io/fs",
+	"gorm":     "gorm.io/gorm",
+	"http":     "net/http",
+	"io":       "io",
+	"json":     "encoding/json",
+	"log":      "log",
+	"mail":     "net/mail",
+	"os":       "os",
+	"reflect":  "reflect",
+	"regexp":   "regexp",
+	"signal":   "os/signal",
+	"slices":   "slices",
+	"sort":     "sort",
+	"sql":      "database/sql",
+	"strconv":  "strconv",
+	"strings":  "strings",
+	"sync":     "sync",
+	"syscall":  "syscall",
+	"testing":  "testing",
+	"time":     "time",
+	"ulid":     "github.com/oklog/ulid/v2",
+	"url":      "net/url",
+	"utf8":     "unicode/utf8",
+	"uuid":     "github.com/google/uuid",
+}
+
+// FormatSource formats Go source like gofmt, dropping unused imports and adding
+// missing ones for well-known packages, the way goimports would.
+func FormatSource(filename string, src []byte) ([]byte, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filename, src, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	if cut, ok := cutImports(fset, src, unusedImports(file)); ok {
+		src = cut
+		fset = token.NewFileSet()
+		if file, err = parser.ParseFile(fset, filename, src, parser.ParseComments); err != nil {
+			return nil, err
+		}
+	}
+
+	missing := fixImports(fset, file)
+
+	var buf bytes.Buffer
+	if err := format.Node(&buf, fset, file); err != nil {
+		return nil, err
+	}
+	src = buf.Bytes()
+
+	if len(missing) > 0 {
+		src, err = addImports(filename, src, missing)
+		if err != nil {
+			return nil, err
+		}
+	}
+	// A second pass sorts the imports and normalizes the blank lines left behind by removed ones.
+	return format.Source(src)
+}
+
+// formatGenerated runs FormatSource on a rendered template and turns syntax
+// errors into a TemplateError pointing at the offending rendered line.
+func formatGenerated(name, destination string, src []byte, data any) ([]byte, error) {
+	formatted, err := FormatSource(destination, src)
+	if err == nil {
+		return formatted, nil
+	}
+
+	tmplErr := &TemplateError{Template: name, Destination: destination, Data: data, Err: err}
+	var list scanner.ErrorList
+	if errors.As(err, &list) && len(list) > 0 {
+		tmplErr.Err = list[0]
+		tmplErr.Line = list[0].Pos.Line
+		lines := strings.Split(string(src), "\n")
+		if tmplErr.Line <= len(lines) {
+			tmplErr.Source = strings.TrimRight(lines[tmplErr.Line-1], "\r")
+		}
+	}
+	return nil, tmplErr
+}
+
+// unusedImports returns the imports of file whose package is never used. Only imports whose
+// package name is known for sure are reported.
+func unusedImports(file *ast.File) []*ast.ImportSpec {
+	used := usedPackages(file)
+	var unused []*ast.ImportSpec
+	for _, imp := range file.Imports {
+		path, _ := strconv.Unquote(imp.Path.Value)
+		if name, certain := importName(imp, path); certain && name != "_" && name != "." && !used[name] {
+			unused = append(unused, imp)
+		}
+	}
+	return unused
+}
+
+// cutImports removes the lines holding imports from src, with their doc comments. Dropping
+// the spec alone would leave its line blank and split its group in two. Imports sharing their
+// line with another spec are left to fixImports. It reports whether any line was cut.
+func cutImports(fset *token.FileSet, src []byte, imports []*ast.ImportSpec) ([]byte, bool) {
+	offset := func(pos token.Pos) int { return fset.Position(pos).Offset }
+	type span struct{ start, end int }
+	var cuts []span
+	for _, imp := range imports {
+		first := imp.Pos()
+		if imp.Doc != nil {
+			first = imp.Doc.Pos()
+		}
+		start, end := lineStart(src, offset(first)), lineEnd(src, offset(imp.End()))
+		before := strings.TrimSpace(string(src[start:offset(first)]))
+		after := strings.TrimSpace(string(src[offset(imp.End()):end]))
+		if (before != "" && before != "import") || (after != "" && !strings.HasPrefix(after, "//")) {
+			continue
+		}
+		cuts = append(cuts, span{start, end})
+	}
+	if len(cuts) == 0 {
+		return src, false
+	}
+
+	var out bytes.Buffer
+	last := 0
+	for _, c := range cuts {
+		out.Write(src[last:c.start])
+		last = c.end
+	}
+	out.Write(src[last:])
+	return out.Bytes(), true
+}
+
+// fixImports drops unused imports from file and returns the import paths of
+// well-known packages that are used without being imported.
+func fixImports(fset *token.FileSet, file *ast.File) []string {
+	unused := unusedImports(file)
+
+	imported := map[string]bool{}
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.IMPORT {
+			continue
+		}
+
+		specs := gen.Specs[:0]
+		for _, spec := range gen.Specs {
+			imp := spec.(*ast.ImportSpec)
+			if slices.Contains(unused, imp) {
+				continue
+			}
+			path, _ := strconv.Unquote(imp.Path.Value)
+			name, _ := importName(imp, path)
+			imported[name] = true
+			specs = append(specs, spec)
+		}
+		gen.Specs = specs
+	}
+	used := usedPackages(file)
+
+	declared := declaredNames(file)
+	var missing []string
+	for name := range used {
+		if path, ok := knownImports[name]; ok && !imported[name] && !declared[name] {
+			missing = append(missing, path)
+		}
+	}
+	sort.Strings(missing)
+
+	// Drop import declarations that ended up empty.
+	decls := file.Decls[:0]
+	for _, decl := range file.Decls {
+		if gen, ok := decl.(*ast.GenDecl); ok && gen.Tok == token.IMPORT && len(gen.Specs) == 0 {
+			continue
+		}
+		decls = append(decls, decl)
+	}
+	file.Decls = decls
+
+	ast.SortImports(fset, file)
+	return missing
+}
+
+// usedPackages collects the identifiers used as selector qualifiers, which may refer to packages.
+func usedPackages(file *ast.File) map[string]bool {
+	used := map[string]bool{}
+	ast.Inspect(file, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if ident, ok := sel.X.(*ast.Ident); ok {
+				used[ident.Name] = true
+			}
+		}
+		return true
+	})
+	return used
+}
+
+// declaredNames collects every name declared in the file, so that a selector on a
+// local variable is never mistaken for a missing import.
+func declaredNames(file *ast.File) map[string]bool {
+	declared := map[string]bool{}
+	ast.Inspect(file, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.Field:
+			for _, name := range n.Names {
+				declared[name.Name] = true
+			}
+		case *ast.ValueSpec:
+			for _, name := range n.Names {
+				declared[name.Name] = true
+			}
+		case *ast.AssignStmt:
+			if n.Tok == token.DEFINE {
+				for _, lhs := range n.Lhs {
+					if ident, ok := lhs.(*ast.Ident); ok {
+						declared[ident.Name] = true
+					}
+				}
+			}
+		case *ast.RangeStmt:
+			if n.Tok == token.DEFINE {
+				for _, e := range []ast.Expr{n.Key, n.Value} {
+					if ident, ok := e.(*ast.Ident); ok {
+						declared[ident.Name] = true
+					}
+				}
+			}
+		}
+		return true
+	})
+	for _, decl := range file.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			if decl.Recv == nil {
+				declared[decl.Name.Name] = true
+			}
+		case *ast.GenDecl:
+			for _, spec := range decl.Specs {
+				if ts, ok := spec.(*ast.TypeSpec); ok {
+					declared[ts.Name.Name] = true
+				}
+			}
+		}
+	}
+	return declared
+}
+
+// importName returns the name an import is referred to by in the file, and whether
+// that name is certain. Package names are only certain for explicitly named imports,
+// the standard library and well-known packages.
+func importName(imp *ast.ImportSpec, path string) (string, bool) {
+	if imp.Name != nil {
+		return imp.Name.Name, true
+	}
+	name := assumedPackageName(path)
+	if known, ok := knownImports[name]; ok && known == path {
+		return name, true
+	}
+	return name, isStdImport(path)
+}
+
+// assumedPackageName guesses a package name from its import path, following goimports' heuristics.
+func assumedPackageName(path string) string {
+	parts := strings.Split(path, "/")
+	name := parts[len(parts)-1]
+	if len(parts) > 1 && isVersionSuffix(name) {
+		name = parts[len(parts)-2]
+	}
+	name = strings.TrimPrefix(name, "go-")
+	if i := strings.IndexAny(name, ".-"); i > 0 {
+		name = name[:i]
+	}
+	return name
+}
+
+func isVersionSuffix(s string) bool {
+	if len(s) < 2 || s[0] != 'v' {
+		return false
+	}
+	_, err := strconv.Atoi(s[1:])
+	return err == nil
+}
+
+// addImports inserts import specs into the first import declaration of src, creating one if
+// needed. Like goimports, it keeps the standard library apart from other packages: a path goes
+// into the first group of its kind, or a new group when there is none.
+func addImports(filename string, src []byte, paths []string) ([]byte, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filename, src, parser.ImportsOnly)
+	if err != nil {
+		return nil, err
+	}
+
+	offset := func(pos token.Pos) int { return fset.Position(pos).Offset }
+	var out bytes.Buffer
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.IMPORT {
+			continue
+		}
+		if !gen.Lparen.IsValid() || len(gen.Specs) == 0 {
+			// Turn a single-line or empty import into a block holding every spec.
+			specs := quoteImports(paths)
+			if len(gen.Specs) > 0 {
+				specs = append(specs, string(src[offset(gen.Specs[0].Pos()):offset(gen.Specs[0].End())]))
+			}
+			out.Write(src[:offset(gen.Pos())])
+			out.WriteString("import (\n" + importBlock(specs) + ")")
+			out.Write(src[offset(gen.End()):])
+			return out.Bytes(), nil
+		}
+
+		// Specs on consecutive lines form a group, blank lines separate groups.
+		var groups [][]ast.Spec
+		line := 0
+		for _, spec := range gen.Specs {
+			if l := fset.Position(spec.Pos()).Line; len(groups) == 0 || l > line+1 {
+				groups = append(groups, nil)
+			}
+			groups[len(groups)-1] = append(groups[len(groups)-1], spec)
+			line = fset.Position(spec.End()).Line
+		}
+		insert := map[int]string{}
+		var newStd, newOther []string
+		for _, path := range paths {
+			std := isStdImport(path)
+			i := slices.IndexFunc(groups, func(group []ast.Spec) bool {
+				if std {
+					return !slices.ContainsFunc(group, func(s ast.Spec) bool { return !isStdImport(importPath(s)) })
+				}
+				return slices.ContainsFunc(group, func(s ast.Spec) bool { return !isStdImport(importPath(s)) })
+			})
+			switch {
+			case i >= 0:
+				at := lineEnd(src, offset(groups[i][len(groups[i])-1].End()))
+				insert[at] += "\t" + strconv.Quote(path) + "\n"
+			case std:
+				newStd = append(newStd, path)
+			default:
+				newOther = append(newOther, path)
+			}
+		}
+		if len(newStd) > 0 {
+			insert[lineEnd(src, offset(gen.Lparen))] += importBlock(quoteImports(newStd)) + "\n"
+		}
+		if len(newOther) > 0 {
+			insert[lineStart(src, offset(gen.Rparen))] += "\n" + importBlock(quoteImports(newOther))
+		}
+
+		at := slices.Sorted(maps.Keys(insert))
+		last := 0
+		for _, o := range at {
+			out.Write(src[last:o])
+			out.WriteString(insert[o])
+			last = o
+		}
+		out.Write(src[last:])
+		return out.Bytes(), nil
+	}
+
+	end := offset(file.Name.End())
+	out.Write(src[:end])
+	out.WriteString("\n\nimport (\n" + importBlock(quoteImports(paths)) + ")\n")
+	out.Write(src[end:])
+	return out.Bytes(), nil
+}
+
+// importBlock lists import specs as the lines of an import block, the standard library first
+// and other packages in a group after it.
+func importBlock(specs []string) string {
+	var std, other strings.Builder
+	for _, spec := range specs {
+		fields := strings.Fields(spec)
+		if path, _ := strconv.Unquote(fields[len(fields)-1]); isStdImport(path) {
+			std.WriteString("\t" + spec + "\n")
+		} else {
+			other.WriteString("\t" + spec + "\n")
+		}
+	}
+	block := std.String()
+	if std.Len() > 0 && other.Len() > 0 {
+		block += "\n"
+	}
+	return block + other.String()
+}
+
+func quoteImports(paths []string) []string {
+	quoted := make([]string, len(paths))
+	for i, path := range paths {
+		quoted[i] = strconv.Quote(path)
+	}
+	return quoted
+}
+
+// isStdImport reports whether path belongs to the standard library, whose first element has no dot.
+func isStdImport(path string) bool {
+	return !strings.Contains(strings.Split(path, "/")[0], ".")
+}
+
+func importPath(spec ast.Spec) string {
+	path, _ := strconv.Unquote(spec.(*ast.ImportSpec).Path.Value)
+	return path
+}
+
+// lineStart returns the offset of the beginning of the line holding offset.
+func lineStart(src []byte, offset int) int {
+	return bytes.LastIndexByte(src[:offset], '\n') + 1
+}
+
+// lineEnd returns the offset just past the newline ending the line holding offset.
+func lineEnd(src []byte, offset int) int {
+	if i := bytes.IndexByte(src[offset:], '\n'); i >= 0 {
+		return offset + i + 1
+	}
+	return len(src)
+}
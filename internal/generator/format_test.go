@@ -0,0 +1,151 @@
+package generator
+
+import "testing"
+
+func TestFormatSourceGroupsImports(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{
+			name: "new block",
+			src:  "package p\n\nvar _ = uuid.New\nvar _ = fmt.Sprint\n",
+			want: "package p\n\nimport (\n\t\"fmt\"\n\n\t\"github.com/google/uuid\"\n)\n\nvar _ = uuid.New\nvar _ = fmt.Sprint\n",
+		},
+		{
+			name: "into the groups of their kind",
+			src: `package p
+
+import (
+	"context"
+
+	"example.com/app/internal/models"
+)
+
+var _ context.Context
+var _ models.User
+var _ = uuid.New
+var _ = time.Now
+`,
+			want: `package p
+
+import (
+	"context"
+	"time"
+
+	"example.com/app/internal/models"
+	"github.com/google/uuid"
+)
+
+var _ context.Context
+var _ models.User
+var _ = uuid.New
+var _ = time.Now
+`,
+		},
+		{
+			name: "new groups around existing ones",
+			src: `package p
+
+import (
+	"example.com/app/internal/models"
+)
+
+var _ models.User
+var _ = time.Now
+`,
+			want: `package p
+
+import (
+	"time"
+
+	"example.com/app/internal/models"
+)
+
+var _ models.User
+var _ = time.Now
+`,
+		},
+		{
+			name: "new group after the standard library",
+			src: `package p
+
+import (
+	"time"
+)
+
+var _ = uuid.New
+var _ = time.Now
+var _ = fmt.Sprint
+`,
+			want: `package p
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var _ = uuid.New
+var _ = time.Now
+var _ = fmt.Sprint
+`,
+		},
+		{
+			name: "single-line import",
+			src:  "package p\n\nimport \"github.com/google/uuid\"\n\nvar _ = uuid.New\nvar _ = time.Now\n",
+			want: "package p\n\nimport (\n\t\"time\"\n\n\t\"github.com/google/uuid\"\n)\n\nvar _ = uuid.New\nvar _ = time.Now\n",
+		},
+		{
+			name: "unused import dropped from the middle of a group",
+			src: `package p
+
+import (
+	"encoding/json"
+	// http serves the handlers
+	"net/http"
+	"strconv"
+
+	"example.com/app/internal/models"
+	"github.com/google/uuid"
+)
+
+var _ = json.Marshal
+var _ = strconv.Itoa
+var _ models.User
+var _ = uuid.New
+var _ = errors.New
+`,
+			want: `package p
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+
+	"example.com/app/internal/models"
+	"github.com/google/uuid"
+)
+
+var _ = json.Marshal
+var _ = strconv.Itoa
+var _ models.User
+var _ = uuid.New
+var _ = errors.New
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FormatSource("p.go", []byte(tt.src))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("FormatSource() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,61 +1,45 @@
 package generator
 
-import (
-	"fmt"
-	"os"
-	"path/filepath"
-)
+import "github.com/theHamdiz/gun/internal/inflect"
 
-func GenerateHandler(resourceName string) error {
+func GenerateHandler(ctx *ProjectContext, resourceName string) error {
+	parents, err := parentRelations(resourceName)
+	if err != nil {
+		return err
+	}
+	schema, err := LoadSchema()
+	if err != nil {
+		return err
+	}
 	data := struct {
+		*ProjectContext
 		ResourceName string
+		Names        inflect.Names
+		// Parents are the resources this one is nested under.
+		Parents []Field
+		// Validated is set for generated models, whose Validate method checks request bodies.
+		Validated bool
+		// Options are the conventions of the model, like the type of its key.
+		Options ModelOptions
+		// Stored is set when the model has a repository, which the handlers then use.
+		Stored bool
 	}{
-		ResourceName: resourceName,
+		ProjectContext: ctx,
+		ResourceName:   resourceName,
+		Names:          inflect.NamesFor(resourceName),
+		Parents:        parents,
 	}
-
-	handlerFilePath := filepath.Join("internal", "handlers", fmt.Sprintf("%s_handler.go", ToSnakeCase(resourceName)))
-	err := os.MkdirAll(filepath.Dir(handlerFilePath), 0755)
+	if m := schema.Model(resourceName); m != nil {
+		data.Validated, data.Options = true, m.ModelOptions
+	}
+	manifest, err := LoadManifest()
 	if err != nil {
 		return err
 	}
+	// The handlers read keys with the Parse function of models generated with --id
+	data.Stored = manifest.Find("repository", resourceName) != nil && data.Options.ID != ""
 
-	return CreateFileFromTemplate(handlerFilePath, HandlerTemplate, data)
-}
-
-// HandlerTemplate is the template for generating handlers
-var HandlerTemplate = `package handlers
-
-import (
-    "github.com/gofiber/fiber/v2"
-    "{{ .ModuleName }}/internal/models"
-)
-
-func Get{{ .ResourceName }}s(c *fiber.Ctx) error {
-    // TODO: Implement logic to retrieve list of {{ .ResourceName }}s
-    return c.JSON(fiber.Map{"message": "List of {{ .ResourceName }}s"})
-}
-
-func Get{{ .ResourceName }}(c *fiber.Ctx) error {
-    // TODO: Implement logic to retrieve a single {{ .ResourceName }}
-    return c.JSON(fiber.Map{"message": "Get {{ .ResourceName }}"})
-}
-
-func Create{{ .ResourceName }}(c *fiber.Ctx) error {
-    var item models.{{ .ResourceName }}
-    if err := c.BodyParser(&item); err != nil {
-        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
-    }
-    // TODO: Save item to database
-    return c.JSON(item)
-}
-
-func Update{{ .ResourceName }}(c *fiber.Ctx) error {
-    // TODO: Implement logic to update {{ .ResourceName }}
-    return c.JSON(fiber.Map{"message": "Update {{ .ResourceName }}"})
-}
-
-func Delete{{ .ResourceName }}(c *fiber.Ctx) error {
-    // TODO: Implement logic to delete {{ .ResourceName }}
-    return c.JSON(fiber.Map{"message": "Delete {{ .ResourceName }}"})
+	return track("handler", resourceName, func() error {
+		return CreateFileFromLayout("", "handler", data)
+	})
 }
-`
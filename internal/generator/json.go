@@ -0,0 +1,297 @@
+package generator
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/theHamdiz/gun/internal/inflect"
+)
+
+// ModelsFromJSON infers models from a sample JSON payload: an object, or an array of objects
+// that are merged so that keys missing from some of them become optional. Nested objects
+// become models of their own, named after their key, and come before the model using them.
+// They are Embedded, as is a top model without an ID: only the others get a table.
+func ModelsFromJSON(name string, sample []byte) ([]ModelDefinition, error) {
+	dec := json.NewDecoder(bytes.NewReader(sample))
+	dec.UseNumber()
+	value, err := decodeOrdered(dec)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+
+	root := &jsonShape{}
+	root.merge(value)
+	for root.kinds["array"] && len(root.kinds) == 1 {
+		root = root.elem
+	}
+	if !root.kinds["object"] {
+		return nil, fmt.Errorf("the sample is not an object or an array of objects")
+	}
+
+	models := &jsonModels{}
+	top, err := models.object(goName(name), "", root)
+	if err != nil {
+		return nil, err
+	}
+	models.embed(top)
+	return models.defs, nil
+}
+
+// jsonShape accumulates what the values seen at one place of a sample have in common.
+type jsonShape struct {
+	kinds map[string]bool // string, time, int, float, bool, null, object and array
+	// objects counts the objects merged, and fields the keys they hold in order of appearance
+	objects int
+	keys    []string
+	fields  map[string]*jsonShape
+	seen    map[string]int
+	elem    *jsonShape
+}
+
+func (s *jsonShape) merge(value any) {
+	if s.kinds == nil {
+		s.kinds = map[string]bool{}
+	}
+	switch v := value.(type) {
+	case nil:
+		s.kinds["null"] = true
+	case bool:
+		s.kinds["bool"] = true
+	case json.Number:
+		if strings.ContainsAny(v.String(), ".eE") {
+			s.kinds["float"] = true
+		} else {
+			s.kinds["int"] = true
+		}
+	case string:
+		if _, err := time.Parse(time.RFC3339, v); err == nil {
+			s.kinds["time"] = true
+		} else {
+			s.kinds["string"] = true
+		}
+	case []any:
+		s.kinds["array"] = true
+		if s.elem == nil {
+			s.elem = &jsonShape{kinds: map[string]bool{}}
+		}
+		for _, item := range v {
+			s.elem.merge(item)
+		}
+	case jsonObject:
+		s.kinds["object"] = true
+		s.objects++
+		if s.fields == nil {
+			s.fields, s.seen = map[string]*jsonShape{}, map[string]int{}
+		}
+		for _, key := range v.keys {
+			if s.fields[key] == nil {
+				s.fields[key] = &jsonShape{}
+				s.keys = append(s.keys, key)
+			}
+			s.fields[key].merge(v.values[key])
+			s.seen[key]++
+		}
+	}
+}
+
+// jsonObject is a decoded object that remembers the order of its keys, so fields come in
+// the order of the sample.
+type jsonObject struct {
+	keys   []string
+	values map[string]any
+}
+
+// decodeOrdered decodes the next value like Decoder.Decode into an any, with objects
+// decoded as jsonObject.
+func decodeOrdered(dec *json.Decoder) (any, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch tok {
+	case json.Delim('{'):
+		obj := jsonObject{values: map[string]any{}}
+		for dec.More() {
+			keyTok, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			key := keyTok.(string)
+			value, err := decodeOrdered(dec)
+			if err != nil {
+				return nil, err
+			}
+			if _, dup := obj.values[key]; !dup {
+				obj.keys = append(obj.keys, key)
+			}
+			obj.values[key] = value
+		}
+		_, err := dec.Token()
+		return obj, err
+	case json.Delim('['):
+		items := []any{}
+		for dec.More() {
+			item, err := decodeOrdered(dec)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+		}
+		_, err := dec.Token()
+		return items, err
+	}
+	return tok, nil
+}
+
+// nullable reports whether a null was seen, and returns the other kinds.
+func (s *jsonShape) nullable() (kinds []string, null bool) {
+	for kind := range s.kinds {
+		if kind == "null" {
+			null = true
+			continue
+		}
+		kinds = append(kinds, kind)
+	}
+	slices.Sort(kinds)
+	return kinds, null
+}
+
+// jsonModels collects the models inferred from a payload or a schema.
+type jsonModels struct {
+	defs []ModelDefinition
+}
+
+func (m *jsonModels) has(name string) bool {
+	return slices.ContainsFunc(m.defs, func(d ModelDefinition) bool { return d.Name == name })
+}
+
+// embed marks the models as Embedded, but the top ones that have an ID: the others are values
+// nested in the models using them, which get no table.
+func (m *jsonModels) embed(top ...string) {
+	for i, def := range m.defs {
+		m.defs[i].Embedded = !slices.Contains(top, def.Name) || !hasField(def.Fields, "ID")
+	}
+}
+
+// modelName names a nested model after its key, prefixed with its parent's name when the
+// key's name is taken.
+func (m *jsonModels) modelName(key, parent string) string {
+	name := goName(inflect.Singular(inflect.Pascal(key)))
+	if name == "" || m.has(name) || name == parent {
+		name = parent + name
+	}
+	for i := 2; m.has(name); i++ {
+		name = fmt.Sprintf("%s%d", strings.TrimRight(name, "0123456789"), i)
+	}
+	return name
+}
+
+// object adds the model of an object shape, after the models of its nested objects.
+func (m *jsonModels) object(name, parent string, s *jsonShape) (string, error) {
+	if name == "" {
+		name = "Item"
+	}
+	if parent != "" || m.has(name) {
+		name = m.modelName(name, parent)
+	}
+	def := ModelDefinition{Name: name}
+	// Reserve the name before nested models pick theirs
+	m.defs = append(m.defs, def)
+	at := len(m.defs) - 1
+
+	for _, key := range s.keys {
+		shape := s.fields[key]
+		typ, null, err := m.goType(key, name, shape)
+		if err != nil {
+			return "", err
+		}
+		f, err := jsonField(m.defs[at].Fields, key, typ, null || s.seen[key] < s.objects)
+		if err != nil {
+			return "", fmt.Errorf("model %s: %w", name, err)
+		}
+		m.defs[at].Fields = append(m.defs[at].Fields, f)
+	}
+
+	// Nested models were appended after this one, move it after them
+	def = m.defs[at]
+	m.defs = append(m.defs[:at], m.defs[at+1:]...)
+	m.defs = append(m.defs, def)
+	return name, nil
+}
+
+// goType returns the Go type of the values of a shape.
+func (m *jsonModels) goType(key, parent string, s *jsonShape) (typ string, null bool, err error) {
+	kinds, null := s.nullable()
+	switch strings.Join(kinds, ",") {
+	case "bool":
+		return "bool", null, nil
+	case "int":
+		return "int", null, nil
+	case "float", "float,int":
+		return "float64", null, nil
+	case "string", "string,time":
+		return "string", null, nil
+	case "time":
+		return "time.Time", null, nil
+	case "object":
+		if len(s.keys) == 0 {
+			return "map[string]any", null, nil
+		}
+		name, err := m.object(key, parent, s)
+		return name, null, err
+	case "array":
+		elem, _, err := m.goType(key, parent, s.elem)
+		if err != nil {
+			return "", false, err
+		}
+		return "[]" + elem, null, nil
+	}
+	// Never seen, or seen with values of different kinds
+	return "any", null, nil
+}
+
+// jsonField makes the field of a JSON key, keeping the key as its json tag. The field is
+// numbered when its name is one of fields already, like XID2 for x_id after x-id.
+func jsonField(fields []Field, key, typ string, optional bool) (Field, error) {
+	name := goName(key)
+	if name == "" {
+		name = "Field"
+	}
+	for i, base := 2, name; hasField(fields, name); i++ {
+		name = fmt.Sprintf("%s%d", base, i)
+	}
+	f := Field{Name: name}
+	if err := f.parseType(fieldToken{text: typ}); err != nil {
+		return Field{}, fmt.Errorf("key %q: %w", key, err)
+	}
+	tag := key
+	switch {
+	case !optional:
+		f.Required = typ != "any"
+	case typ == "any":
+		f.Optional = true
+		tag += ",omitempty"
+	default:
+		f.makeOptional()
+		tag += ",omitempty"
+	}
+	f.TagValues = map[string]string{"json": tag}
+	return f, nil
+}
+
+// goName turns a JSON key or title into an exported Go name made of its words: "x-id" is XID,
+// and "2fa" is X2fa since a name cannot start with a digit. It is empty when s has no letter
+// or digit.
+func goName(s string) string {
+	name := inflect.Pascal(s)
+	if r, _ := utf8.DecodeRuneInString(name); name != "" && !unicode.IsUpper(r) {
+		name = "X" + name
+	}
+	return name
+}
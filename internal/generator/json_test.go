@@ -0,0 +1,100 @@
+package generator
+
+import (
+	"slices"
+	"testing"
+)
+
+// modelsString renders models one per line as Name: fields, in the --fields grammar, with
+// embedded models marked by a leading ~.
+func modelsString(defs []ModelDefinition) []string {
+	lines := make([]string, len(defs))
+	for i, d := range defs {
+		lines[i] = d.Name + ": " + fieldsString(d.Fields)
+		if d.Embedded {
+			lines[i] = "~" + lines[i]
+		}
+	}
+	return lines
+}
+
+func TestModelsFromJSON(t *testing.T) {
+	sample := `[
+		{"id": 1, "2fa": true, "x-id": "a", "x_id": "b", "_": 0, "created_at": "2024-01-01T00:00:00Z",
+		 "address": {"city": "Cairo", "zip": null}, "tags": ["a"], "items": [{"sku": "x", "price": 1.5}], "meta": {}},
+		{"id": 2, "2fa": false, "x-id": "c", "x_id": "d", "_": 1, "created_at": "2024-01-02T00:00:00Z",
+		 "address": {"city": "Giza", "zip": "12"}, "tags": [], "items": [{"sku": "y", "price": 2}], "meta": {}, "extra": 1}
+	]`
+	want := []string{
+		`~Address: City:string:required:json="city" Zip:*string:optional:json="zip,omitempty"`,
+		`~Item: Sku:string:required:json="sku" Price:float64:required:json="price"`,
+		`User: ID:int:required:json="id" X2fa:bool:required:json="2fa" XID:string:required:json="x-id" XID2:string:required:json="x_id" ` +
+			`Field:int:required:json="_" CreatedAt:time.Time:required:json="created_at" Address:Address:required:json="address" ` +
+			`Tags:[]string:required:json="tags" Items:[]Item:required:json="items" Meta:map[string]any:required:json="meta" ` +
+			`Extra:*int:optional:json="extra,omitempty"`,
+	}
+	defs, err := ModelsFromJSON("user", []byte(sample))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := modelsString(defs); !slices.Equal(got, want) {
+		t.Errorf("ModelsFromJSON() =\n%q\nwant\n%q", got, want)
+	}
+
+	if _, err := ModelsFromJSON("user", []byte(`[1, 2]`)); err == nil {
+		t.Error("ModelsFromJSON() of an array of numbers succeeded, want an error")
+	}
+}
+
+func TestModelsFromJSONSchema(t *testing.T) {
+	schema := `{
+		"title": "order",
+		"type": "object",
+		"required": ["id", "2fa"],
+		"properties": {
+			"id": {"type": "integer", "format": "int64"},
+			"2fa": {"type": "boolean"},
+			"x-id": {"type": "string", "format": "email", "maxLength": 10},
+			"status": {"enum": ["new", "paid"], "default": "new"},
+			"customer": {"$ref": "#/$defs/customer"},
+			"lines": {"type": "array", "items": {"type": "object", "title": "line", "properties": {"qty": {"type": "integer", "minimum": 1}}}},
+			"at": {"type": ["string", "null"], "format": "date-time"}
+		},
+		"$defs": {
+			"customer": {"allOf": [{"$ref": "#/$defs/named"}], "properties": {"email": {"type": "string"}}},
+			"named": {"type": "object", "properties": {"name": {"type": "string"}}, "required": ["name"]}
+		}
+	}`
+	want := []string{
+		`~Customer: Email:*string:optional:json="email,omitempty" Name:string:required:json="name"`,
+		`~Line: Qty:*int:optional:min=1:json="qty,omitempty"`,
+		`Order: ID:int64:required:json="id" X2fa:bool:required:json="2fa" XID:*string:optional:email:max=10:json="x-id,omitempty" ` +
+			`Status:*string:optional:oneof=new|paid:default="new":json="status,omitempty" Customer:*Customer:optional:json="customer,omitempty" ` +
+			`Lines:[]Line:optional:json="lines,omitempty" At:*time.Time:optional:json="at,omitempty"`,
+	}
+	defs, err := ModelsFromJSONSchema("", []byte(schema))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := modelsString(defs); !slices.Equal(got, want) {
+		t.Errorf("ModelsFromJSONSchema() =\n%q\nwant\n%q", got, want)
+	}
+
+	// A schema of definitions only makes a model of each object, named after its key, with a
+	// table when it has an ID
+	defs, err = ModelsFromJSONSchema("", []byte(`{"definitions": {
+		"2d-point": {"type": "object", "properties": {"x": {"type": "number"}}},
+		"id": {"type": "string"},
+		"shape": {"type": "object", "properties": {"id": {"type": "integer"}, "origin": {"$ref": "#/definitions/2d-point"}}}
+	}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = []string{
+		`~X2dPoint: X:*float64:optional:json="x,omitempty"`,
+		`Shape: ID:*int:optional:json="id,omitempty" Origin:*X2dPoint:optional:json="origin,omitempty"`,
+	}
+	if got := modelsString(defs); !slices.Equal(got, want) {
+		t.Errorf("ModelsFromJSONSchema() of definitions = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,497 @@
+package generator
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// jsonSchema is the part of a JSON Schema (draft 4 to 2020-12, and OpenAPI's nullable) that
+// maps onto models.
+type jsonSchema struct {
+	Ref                  string                 `json:"$ref"`
+	Title                string                 `json:"title"`
+	Type                 jsonSchemaType         `json:"type"`
+	Format               string                 `json:"format"`
+	Properties           json.RawMessage        `json:"properties"`
+	Required             []string               `json:"required"`
+	Items                *jsonSchema            `json:"items"`
+	AdditionalProperties json.RawMessage        `json:"additionalProperties"`
+	Enum                 []json.RawMessage      `json:"enum"`
+	Default              json.RawMessage        `json:"default"`
+	Nullable             bool                   `json:"nullable"`
+	Minimum              json.Number            `json:"minimum"`
+	Maximum              json.Number            `json:"maximum"`
+	MinLength            json.Number            `json:"minLength"`
+	MaxLength            json.Number            `json:"maxLength"`
+	MinItems             json.Number            `json:"minItems"`
+	MaxItems             json.Number            `json:"maxItems"`
+	AllOf                []*jsonSchema          `json:"allOf"`
+	AnyOf                []*jsonSchema          `json:"anyOf"`
+	OneOf                []*jsonSchema          `json:"oneOf"`
+	Definitions          map[string]*jsonSchema `json:"definitions"`
+	Defs                 map[string]*jsonSchema `json:"$defs"`
+
+	// merged holds the properties of an object merged with its allOf parts
+	merged []property
+}
+
+// jsonSchemaType is the type keyword, a single type or a list of them.
+type jsonSchemaType []string
+
+func (t *jsonSchemaType) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+	var one string
+	if err := json.Unmarshal(data, &one); err == nil {
+		*t = jsonSchemaType{one}
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(data, &many); err != nil {
+		return fmt.Errorf("type must be a string or a list of strings")
+	}
+	*t = many
+	return nil
+}
+
+// property is a property of an object schema.
+type property struct {
+	name   string
+	schema *jsonSchema
+}
+
+// properties returns the properties of s in the order the schema lists them.
+func (s *jsonSchema) properties() ([]property, error) {
+	if s.merged != nil {
+		return s.merged, nil
+	}
+	if len(s.Properties) == 0 {
+		return nil, nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(s.Properties))
+	value, err := decodeOrdered(dec)
+	if err != nil {
+		return nil, err
+	}
+	obj, ok := value.(jsonObject)
+	if !ok {
+		return nil, fmt.Errorf("properties must be an object")
+	}
+	var schemas map[string]*jsonSchema
+	if err := json.Unmarshal(s.Properties, &schemas); err != nil {
+		return nil, err
+	}
+	props := make([]property, 0, len(obj.keys))
+	for _, key := range obj.keys {
+		props = append(props, property{name: key, schema: schemas[key]})
+	}
+	return props, nil
+}
+
+func (s *jsonSchema) is(typ string) bool {
+	return slices.Contains(s.Type, typ)
+}
+
+// nullable reports whether null is one of the types of s.
+func (s *jsonSchema) nullable() bool {
+	return s.Nullable || s.is("null")
+}
+
+// ModelsFromJSONSchema maps an object JSON Schema to models: nested objects and $ref'd
+// definitions become models of their own, named after their title, definition or key.
+// A schema holding only definitions generates a model for each object among them. Only the
+// top models with an ID get a table, the nested ones and the others are Embedded.
+func ModelsFromJSONSchema(name string, data []byte) ([]ModelDefinition, error) {
+	root := &jsonSchema{}
+	if err := json.Unmarshal(data, root); err != nil {
+		return nil, fmt.Errorf("invalid JSON Schema: %w", err)
+	}
+	w := &schemaWalker{root: root, refs: map[string]string{}, building: map[string]bool{}}
+
+	if !root.isObject() && root.Ref == "" {
+		defs := root.definitions()
+		if len(defs) == 0 {
+			return nil, fmt.Errorf("the schema has no properties and no definitions")
+		}
+		keys := make([]string, 0, len(defs))
+		for key := range defs {
+			keys = append(keys, key)
+		}
+		slices.Sort(keys)
+		var top []string
+		for _, key := range keys {
+			if defs[key].isObject() {
+				name, _, err := w.resolve(w.refPath(key))
+				if err != nil {
+					return nil, err
+				}
+				top = append(top, name)
+			}
+		}
+		w.models.embed(top...)
+		return w.models.defs, nil
+	}
+
+	if name == "" {
+		name = root.Title
+	}
+	if name == "" {
+		return nil, fmt.Errorf("the schema has no title, name the model")
+	}
+	object, err := w.object(root)
+	if err != nil {
+		return nil, err
+	}
+	// The schema may refer to itself with "#"
+	w.refs["#"] = goName(name)
+	top, err := w.model(goName(name), object)
+	if err != nil {
+		return nil, err
+	}
+	w.models.embed(top)
+	return w.models.defs, nil
+}
+
+func (s *jsonSchema) definitions() map[string]*jsonSchema {
+	if len(s.Defs) > 0 {
+		return s.Defs
+	}
+	return s.Definitions
+}
+
+// isObject reports whether s describes an object with known properties, rather than a
+// scalar or a dictionary.
+func (s *jsonSchema) isObject() bool {
+	return len(s.Properties) > 0 || len(s.AllOf) > 0 || s.merged != nil
+}
+
+type schemaWalker struct {
+	root   *jsonSchema
+	models jsonModels
+	// refs maps the $refs already generated to their model, and building the models being
+	// generated, which their own fields refer to through pointers.
+	refs     map[string]string
+	building map[string]bool
+}
+
+func (w *schemaWalker) refPath(key string) string {
+	if len(w.root.Defs) > 0 {
+		return "#/$defs/" + key
+	}
+	return "#/definitions/" + key
+}
+
+// lookup finds the schema a local $ref like #/definitions/Address points to.
+func (w *schemaWalker) lookup(ref string) (*jsonSchema, string, error) {
+	for _, prefix := range []string{"#/$defs/", "#/definitions/", "#/components/schemas/"} {
+		if key, ok := strings.CutPrefix(ref, prefix); ok {
+			if s := w.root.definitions()[key]; s != nil {
+				return s, key, nil
+			}
+		}
+	}
+	if ref == "#" {
+		return w.root, w.root.Title, nil
+	}
+	return nil, "", fmt.Errorf("unsupported $ref %q (only local definitions are)", ref)
+}
+
+// resolve returns the type of a $ref, generating the model of an object definition once.
+func (w *schemaWalker) resolve(ref string) (typ string, s *jsonSchema, err error) {
+	s, key, err := w.lookup(ref)
+	if err != nil {
+		return "", nil, err
+	}
+	if !s.isObject() {
+		return "", s, nil
+	}
+	if name, ok := w.refs[ref]; ok {
+		if w.building[name] {
+			return "*" + name, s, nil
+		}
+		return name, s, nil
+	}
+	name := goName(key)
+	if name == "" {
+		name = goName(s.Title)
+	}
+	w.refs[ref] = name
+	object, err := w.object(s)
+	if err != nil {
+		return "", nil, err
+	}
+	name, err = w.model(name, object)
+	w.refs[ref] = name
+	return name, s, err
+}
+
+// object merges the properties of an object schema and of its allOf parts.
+func (w *schemaWalker) object(s *jsonSchema) (*jsonSchema, error) {
+	if s.Ref != "" {
+		target, _, err := w.lookup(s.Ref)
+		if err != nil {
+			return nil, err
+		}
+		return w.object(target)
+	}
+	if len(s.AllOf) == 0 {
+		return s, nil
+	}
+	merged := &jsonSchema{Title: s.Title, merged: []property{}}
+	own := &jsonSchema{Properties: s.Properties, Required: s.Required}
+	for _, part := range append([]*jsonSchema{own}, s.AllOf...) {
+		part, err := w.object(part)
+		if err != nil {
+			return nil, err
+		}
+		props, err := part.properties()
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range props {
+			if !slices.ContainsFunc(merged.merged, func(q property) bool { return q.name == p.name }) {
+				merged.merged = append(merged.merged, p)
+			}
+		}
+		merged.Required = append(merged.Required, part.Required...)
+	}
+	return merged, nil
+}
+
+// model adds the model of an object schema, after the models of its nested objects.
+func (w *schemaWalker) model(name string, s *jsonSchema) (string, error) {
+	if w.models.has(name) {
+		name = w.models.modelName(name, "")
+	}
+	w.building[name] = true
+	defer delete(w.building, name)
+
+	at := len(w.models.defs)
+	w.models.defs = append(w.models.defs, ModelDefinition{Name: name})
+
+	props, err := s.properties()
+	if err != nil {
+		return "", fmt.Errorf("model %s: %w", name, err)
+	}
+	for _, p := range props {
+		if p.schema == nil {
+			continue
+		}
+		typ, null, err := w.goType(p.name, name, p.schema)
+		if err != nil {
+			return "", fmt.Errorf("model %s: property %q: %w", name, p.name, err)
+		}
+		required := slices.Contains(s.Required, p.name)
+		f, err := jsonField(w.models.defs[at].Fields, p.name, typ, !required || null)
+		if err != nil {
+			return "", fmt.Errorf("model %s: %w", name, err)
+		}
+		w.constrain(&f, p.schema)
+		w.models.defs[at].Fields = append(w.models.defs[at].Fields, f)
+	}
+
+	def := w.models.defs[at]
+	w.models.defs = append(w.models.defs[:at], w.models.defs[at+1:]...)
+	w.models.defs = append(w.models.defs, def)
+	return name, nil
+}
+
+// constrain maps the validation keywords of a property onto the field's modifiers.
+func (w *schemaWalker) constrain(f *Field, s *jsonSchema) {
+	if s.Ref != "" {
+		if target, _, err := w.lookup(s.Ref); err == nil {
+			s = target
+		}
+	}
+	if strings.TrimPrefix(f.Type, "*") == "string" {
+		switch s.Format {
+		case "email":
+			f.Email = true
+		case "uri", "url":
+			f.URL = true
+		}
+	}
+	if values := enumValues(s.Enum); len(values) > 0 {
+		// Enums the DSL cannot hold, like booleans, are left unchecked
+		_ = f.setOneOf(values)
+	}
+	for _, bound := range []struct {
+		value json.Number
+		into  *string
+	}{
+		{s.Minimum, &f.Min}, {s.Maximum, &f.Max},
+		{s.MinLength, &f.Min}, {s.MaxLength, &f.Max},
+		{s.MinItems, &f.Min}, {s.MaxItems, &f.Max},
+	} {
+		if bound.value != "" {
+			*bound.into = bound.value.String()
+		}
+	}
+	if len(s.Default) > 0 && !bytes.Equal(s.Default, []byte("null")) {
+		value := string(s.Default)
+		if unquoted, err := strconv.Unquote(value); err == nil {
+			value = unquoted
+		}
+		// Objects and arrays have no literal default
+		if !strings.HasPrefix(value, "{") && !strings.HasPrefix(value, "[") {
+			f.Default, f.HasDefault = value, true
+		}
+	}
+}
+
+// goType returns the Go type of a property schema, and whether it allows null.
+func (w *schemaWalker) goType(key, parent string, s *jsonSchema) (typ string, null bool, err error) {
+	null = s.nullable()
+	if s.Ref != "" {
+		typ, target, err := w.resolve(s.Ref)
+		if err != nil || typ != "" {
+			return typ, null, err
+		}
+		// A definition of a scalar, like an enum of strings
+		t, targetNull, err := w.goType(key, parent, target)
+		return t, null || targetNull, err
+	}
+
+	alternatives := append(slices.Clone(s.AnyOf), s.OneOf...)
+	if len(alternatives) > 0 {
+		var types []string
+		for _, alt := range alternatives {
+			if slices.Equal(alt.Type, []string{"null"}) {
+				null = true
+				continue
+			}
+			t, altNull, err := w.goType(key, parent, alt)
+			if err != nil {
+				return "", false, err
+			}
+			null = null || altNull
+			if !slices.Contains(types, t) {
+				types = append(types, t)
+			}
+		}
+		if len(types) == 1 {
+			return types[0], null, nil
+		}
+		return "any", null, nil
+	}
+
+	types := slices.DeleteFunc(slices.Clone(s.Type), func(t string) bool { return t == "null" })
+	if len(types) == 0 {
+		switch {
+		case s.isObject():
+			types = []string{"object"}
+		case len(s.Enum) > 0:
+			types = []string{enumType(s.Enum)}
+		}
+	}
+	if len(types) != 1 {
+		return "any", null, nil
+	}
+
+	switch types[0] {
+	case "string":
+		switch s.Format {
+		case "date-time":
+			return "time.Time", null, nil
+		case "byte", "binary":
+			return "[]byte", null, nil
+		}
+		return "string", null, nil
+	case "integer":
+		switch s.Format {
+		case "int64":
+			return "int64", null, nil
+		case "int32":
+			return "int32", null, nil
+		}
+		return "int", null, nil
+	case "number":
+		if s.Format == "float" {
+			return "float32", null, nil
+		}
+		return "float64", null, nil
+	case "boolean":
+		return "bool", null, nil
+	case "array":
+		if s.Items == nil {
+			return "[]any", null, nil
+		}
+		elem, _, err := w.goType(key, parent, s.Items)
+		if err != nil {
+			return "", false, err
+		}
+		return "[]" + strings.TrimPrefix(elem, "*"), null, nil
+	case "object":
+		if s.isObject() {
+			object, err := w.object(s)
+			if err != nil {
+				return "", false, err
+			}
+			name := s.Title
+			if name == "" {
+				name = key
+			}
+			name, err = w.model(w.models.modelName(name, parent), object)
+			return name, null, err
+		}
+		// A dictionary, like {"additionalProperties": {"type": "integer"}}
+		value := "any"
+		if len(s.AdditionalProperties) > 0 && s.AdditionalProperties[0] == '{' {
+			var values jsonSchema
+			if err := json.Unmarshal(s.AdditionalProperties, &values); err != nil {
+				return "", false, err
+			}
+			if value, _, err = w.goType(key, parent, &values); err != nil {
+				return "", false, err
+			}
+		}
+		return "map[string]" + value, null, nil
+	}
+	return "any", null, nil
+}
+
+// enumValues returns the values of an enum of strings or numbers, without null.
+func enumValues(values []json.RawMessage) []string {
+	var out []string
+	for _, v := range values {
+		if bytes.Equal(v, []byte("null")) {
+			continue
+		}
+		value := string(v)
+		if unquoted, err := strconv.Unquote(value); err == nil {
+			value = unquoted
+		}
+		out = append(out, value)
+	}
+	return out
+}
+
+// enumType returns the JSON Schema type of the values of an enum without a type.
+func enumType(values []json.RawMessage) string {
+	var typ string
+	for _, v := range values {
+		var t string
+		switch {
+		case bytes.Equal(v, []byte("null")):
+			continue
+		case len(v) > 0 && v[0] == '"':
+			t = "string"
+		case bytes.Equal(v, []byte("true")), bytes.Equal(v, []byte("false")):
+			t = "boolean"
+		case bytes.ContainsAny(v, ".eE"):
+			t = "number"
+		default:
+			t = "integer"
+		}
+		if typ != "" && typ != t {
+			return ""
+		}
+		typ = t
+	}
+	return typ
+}
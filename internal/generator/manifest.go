@@ -0,0 +1,239 @@
+package generator
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// ManifestPath is where gun records what every generator wrote, relative to the project.
+var ManifestPath = filepath.Join(".gun", "manifest.json")
+
+// GeneratedPath is where gun keeps the content of the files recorded in the manifest, named
+// after their hash. Merging a file edited since it was generated starts from it.
+var GeneratedPath = filepath.Join(".gun", "generated")
+
+// Manifest is the record of every resource generated in a project.
+// It is what allows `gun destroy` to undo a generator without guessing paths.
+type Manifest struct {
+	Resources []Resource `json:"resources"`
+}
+
+// Resource is the output of one generator run.
+type Resource struct {
+	Kind  string          `json:"kind"`
+	Name  string          `json:"name"`
+	Files []GeneratedFile `json:"files"`
+	// Dirs are the directories created for the files, removed again when they end up empty.
+	Dirs []string `json:"dirs,omitempty"`
+	// Registrations are the calls injected into other files, like the route registration in the router.
+	Registrations []Registration `json:"registrations,omitempty"`
+}
+
+// GeneratedFile is a file written by a generator, with the hash of its content at the time.
+type GeneratedFile struct {
+	Path   string `json:"path"`
+	SHA256 string `json:"sha256"`
+}
+
+// LoadManifest reads the project manifest. A missing manifest is an empty one.
+func LoadManifest() (*Manifest, error) {
+	m := &Manifest{}
+	data, err := os.ReadFile(ManifestPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return m, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Save writes the manifest back. It bypasses the planning layer, since the
+// manifest is gun's own bookkeeping rather than generated code.
+func (m *Manifest) Save() error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(ManifestPath), 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(ManifestPath, append(data, '\n'), 0644); err != nil {
+		return err
+	}
+	return m.pruneGenerated()
+}
+
+// pruneGenerated removes the content kept for files the manifest no longer records.
+func (m *Manifest) pruneGenerated() error {
+	entries, err := os.ReadDir(GeneratedPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	recorded := map[string]bool{}
+	for _, r := range m.Resources {
+		for _, f := range r.Files {
+			recorded[f.SHA256] = true
+		}
+	}
+	for _, e := range entries {
+		if !recorded[e.Name()] {
+			if err := os.Remove(filepath.Join(GeneratedPath, e.Name())); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// keepGenerated stores the content of a generated file under its hash.
+func keepGenerated(content []byte) error {
+	path := filepath.Join(GeneratedPath, hash(content))
+	if _, err := os.Stat(path); err == nil {
+		return nil
+	}
+	if err := os.MkdirAll(GeneratedPath, 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, content, 0644)
+}
+
+// generated returns the content path was last generated with, when the manifest records it.
+func (m *Manifest) generated(path string) ([]byte, bool) {
+	path = filepath.ToSlash(path)
+	for i := len(m.Resources) - 1; i >= 0; i-- {
+		for _, f := range m.Resources[i].Files {
+			if f.Path != path {
+				continue
+			}
+			if content, err := os.ReadFile(filepath.Join(GeneratedPath, f.SHA256)); err == nil && hash(content) == f.SHA256 {
+				return content, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// Find returns the resource generated for kind and name, or nil.
+func (m *Manifest) Find(kind, name string) *Resource {
+	for i := range m.Resources {
+		r := &m.Resources[i]
+		if r.Kind == kind && sameName(r.Name, name) {
+			return r
+		}
+	}
+	return nil
+}
+
+// Put records a resource, merging it with what an earlier run of the same generator wrote.
+func (m *Manifest) Put(res Resource) {
+	existing := m.Find(res.Kind, res.Name)
+	if existing == nil {
+		m.Resources = append(m.Resources, res)
+		return
+	}
+
+	for _, f := range res.Files {
+		replaced := false
+		for i := range existing.Files {
+			if existing.Files[i].Path == f.Path {
+				existing.Files[i] = f
+				replaced = true
+			}
+		}
+		if !replaced {
+			existing.Files = append(existing.Files, f)
+		}
+	}
+	for _, dir := range res.Dirs {
+		if !slices.Contains(existing.Dirs, dir) {
+			existing.Dirs = append(existing.Dirs, dir)
+		}
+	}
+	for _, reg := range res.Registrations {
+		if !slices.Contains(existing.Registrations, reg) {
+			existing.Registrations = append(existing.Registrations, reg)
+		}
+	}
+}
+
+// Remove forgets the resource generated for kind and name.
+func (m *Manifest) Remove(kind, name string) {
+	resources := m.Resources[:0]
+	for _, r := range m.Resources {
+		if r.Kind == kind && sameName(r.Name, name) {
+			continue
+		}
+		resources = append(resources, r)
+	}
+	m.Resources = resources
+}
+
+// track runs a generator and records the files it wrote in the manifest, including those
+// written before it failed, so that `gun destroy` can clean up after it.
+// Files that were skipped or merged into an existing file are not claimed by the generator.
+func track(kind, name string, generate func() error) error {
+	start := plan.mark()
+	err := generate()
+	if IsDryRun() {
+		return err
+	}
+	return errors.Join(err, record(kind, name, plan.since(start)))
+}
+
+// record adds the changes of a generator run to the manifest.
+func record(kind, name string, changes []Change) error {
+	res := Resource{Kind: kind, Name: name}
+	for _, c := range changes {
+		if c.Backup {
+			continue
+		}
+		if c.Registration != nil {
+			res.Registrations = append(res.Registrations, *c.Registration)
+			continue
+		}
+		switch c.Action {
+		case ActionCreate, ActionModify, ActionUnchanged:
+			if err := keepGenerated(c.After); err != nil {
+				return err
+			}
+			res.Files = append(res.Files, GeneratedFile{Path: filepath.ToSlash(c.Path), SHA256: hash(c.After)})
+			for _, dir := range c.Dirs {
+				res.Dirs = append(res.Dirs, filepath.ToSlash(dir))
+			}
+		}
+	}
+	if len(res.Files) == 0 && len(res.Registrations) == 0 {
+		return nil
+	}
+
+	m, err := LoadManifest()
+	if err != nil {
+		return err
+	}
+	m.Put(res)
+	return m.Save()
+}
+
+func hash(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// sameName matches resource names regardless of how they were cased on the command line.
+func sameName(a, b string) bool {
+	return a == b || ToSnakeCase(a) == ToSnakeCase(b) || strings.EqualFold(a, b)
+}
@@ -0,0 +1,752 @@
+package generator
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/theHamdiz/gun/internal/inflect"
+	"github.com/theHamdiz/it"
+)
+
+// migrationData is what the migration templates render: the statements applying the
+// migration and those reverting it.
+type migrationData struct {
+	*ProjectContext
+	// Version orders migrations, as the UTC time they were generated, like 20240102150405.
+	Version string
+	Name    string
+	Dialect string
+	Up      []string
+	Down    []string
+}
+
+// migrationFile matches the files of a migration, like 20240102150405_create_posts.up.sql.
+var migrationFile = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// GenerateMigration writes an empty migration to fill in by hand.
+func GenerateMigration(ctx *ProjectContext, name string) error {
+	name = inflect.Snake(name)
+	if name == "" {
+		return errors.New("missing the migration name")
+	}
+	if err := writeMigrator(ctx); err != nil {
+		return err
+	}
+	return track("migration", name, func() error {
+		return writeMigration(ctx, name, nil, nil)
+	})
+}
+
+// writeMigrator writes the package applying the migrations when the app starts, with a copy
+// of the migrator of gun db and the command gun db runs, which registers the driver of the
+// dialect. Every migration shares them, so they are not tracked with them.
+func writeMigrator(ctx *ProjectContext) error {
+	data := migrationData{ProjectContext: ctx, Dialect: strings.ToLower(ctx.Config.Dialect)}
+	layouts := []string{"db/migrator", "db/migrate", "db/migrate_test", "db/open", "db/command"}
+	// The tests of the migrator run on SQLite, whose driver other projects do not need
+	if data.Dialect == "sqlite" {
+		layouts = append(layouts, "db/migrator_test")
+	}
+	for _, layout := range layouts {
+		if err := CreateFileFromLayout("", layout, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MigrateCommand returns the directory of the command applying the migrations of the project,
+// like cmd/migrate, after writing it when the project has none yet.
+func MigrateCommand(ctx *ProjectContext) (string, error) {
+	dest, err := ActivePack().Destination("db/command", migrationData{ProjectContext: ctx})
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(dest); errors.Is(err, fs.ErrNotExist) {
+		if err := writeMigrator(ctx); err != nil {
+			return "", err
+		}
+	}
+	return filepath.FromSlash(path.Dir(dest)), nil
+}
+
+// writeMigration writes the up and down files of a new migration. A migration may have been
+// applied already, so one of the same name is never rewritten.
+func writeMigration(ctx *ProjectContext, name string, up, down []string) error {
+	dir, err := MigrationsDir(ctx)
+	if err != nil {
+		return err
+	}
+	version, exists, err := migrationVersion(dir, name)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("migration %s exists already as version %s", name, version)
+	}
+	data := migrationData{ProjectContext: ctx, Version: version, Name: name, Dialect: strings.ToLower(ctx.Config.Dialect), Up: up, Down: down}
+	if err := CreateFileFromLayout("", "migration/up", data); err != nil {
+		return err
+	}
+	return CreateFileFromLayout("", "migration/down", data)
+}
+
+// MigrationsDir is the directory the active pack writes migrations to.
+func MigrationsDir(ctx *ProjectContext) (string, error) {
+	dest, err := ActivePack().Destination("migration/up", migrationData{ProjectContext: ctx, Version: "0", Name: "name"})
+	if err != nil {
+		return "", err
+	}
+	return filepath.FromSlash(path.Dir(dest)), nil
+}
+
+// migrationVersion returns the version of the migration named name and whether it exists.
+// Otherwise it returns the next version: the current time, or one past the latest migration
+// when that is not later.
+func migrationVersion(dir, name string) (string, bool, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return "", false, err
+	}
+	var latest uint64
+	for _, e := range entries {
+		m := migrationFile.FindStringSubmatch(e.Name())
+		if m == nil {
+			continue
+		}
+		if m[2] == name {
+			return m[1], true, nil
+		}
+		if v, err := strconv.ParseUint(m[1], 10, 64); err == nil && v > latest {
+			latest = v
+		}
+	}
+	now, _ := strconv.ParseUint(time.Now().UTC().Format("20060102150405"), 10, 64)
+	if now <= latest {
+		now = latest + 1
+	}
+	return strconv.FormatUint(now, 10), false, nil
+}
+
+// tableColumn is a column of a generated table.
+type tableColumn struct {
+	Field Field
+	Name  string
+	// References is the table the column is a foreign key to.
+	References string
+}
+
+// columnName is the column a field is stored in: its db tag, or its name in snake_case.
+func columnName(f Field) string {
+	if name, _, _ := strings.Cut(reflect.StructTag(f.Tag).Get("db"), ","); name != "" {
+		return name
+	}
+	return inflect.Snake(f.Name)
+}
+
+// tableColumns returns the columns of the fields of a table. references maps foreign key
+// fields to the tables they refer to.
+func tableColumns(fields []Field, references map[string]string) []tableColumn {
+	var columns []tableColumn
+	for _, f := range fields {
+		if f.Relation != nil || reflect.StructTag(f.Tag).Get("db") == "-" {
+			continue
+		}
+		columns = append(columns, tableColumn{Field: f, Name: columnName(f), References: references[f.Name]})
+	}
+	return columns
+}
+
+// indexed reports whether a column of a table with its own key gets an index. Unique columns
+// and the key are indexed already.
+func indexed(c tableColumn) bool {
+	return c.Field.Index && !c.Field.Unique && c.Field.Name != "ID"
+}
+
+func indexName(table string, c tableColumn) string {
+	return "idx_" + table + "_" + c.Name
+}
+
+func createIndex(dialect, table string, c tableColumn) string {
+	return fmt.Sprintf("CREATE INDEX %s ON %s (%s)", quoteIdent(dialect, indexName(table, c)), quoteIdent(dialect, table), quoteIdent(dialect, c.Name))
+}
+
+func dropIndex(dialect, table string, c tableColumn) string {
+	if dialect == "mysql" {
+		return fmt.Sprintf("DROP INDEX %s ON %s", quoteIdent(dialect, indexName(table, c)), quoteIdent(dialect, table))
+	}
+	return "DROP INDEX " + quoteIdent(dialect, indexName(table, c))
+}
+
+// createTable renders the statements creating the table of a model and those dropping it.
+// references maps foreign key fields to the tables they refer to. Tables with a composite key,
+// like join tables, list its fields in key.
+func createTable(dialect, table string, fields []Field, references map[string]string, key []string) (up, down []string) {
+	columns := tableColumns(fields, references)
+
+	var defs, indexes, types []string
+	for _, c := range columns {
+		defs = append(defs, columnDefinition(dialect, table, c.Field, c.Name, len(key) == 0))
+		if enum := c.Field.Enum; len(enum) > 0 && dialect == "postgres" {
+			typ := enumTypeName(c.Field)
+			types = append(types, fmt.Sprintf("CREATE TYPE %s AS ENUM (%s)", quoteIdent(dialect, typ), sqlStrings(enum)))
+		}
+		// The primary key indexes its first column already
+		leading := len(key) > 0 && key[0] == c.Field.Name
+		if indexed(c) && !leading {
+			indexes = append(indexes, createIndex(dialect, table, c))
+		}
+	}
+	if len(key) > 0 {
+		names := make([]string, len(key))
+		for i, k := range key {
+			for _, c := range columns {
+				if c.Field.Name == k {
+					names[i] = quoteIdent(dialect, c.Name)
+				}
+			}
+		}
+		defs = append(defs, "PRIMARY KEY ("+strings.Join(names, ", ")+")")
+	}
+	for _, c := range columns {
+		if c.References != "" {
+			defs = append(defs, fmt.Sprintf("FOREIGN KEY (%s) REFERENCES %s (%s)", quoteIdent(dialect, c.Name), quoteIdent(dialect, c.References), quoteIdent(dialect, "id")))
+		}
+	}
+
+	up = append(up, types...)
+	up = append(up, fmt.Sprintf("CREATE TABLE %s (\n    %s\n)", quoteIdent(dialect, table), strings.Join(defs, ",\n    ")))
+	up = append(up, indexes...)
+
+	down = append(down, "DROP TABLE "+quoteIdent(dialect, table))
+	for _, c := range columns {
+		if len(c.Field.Enum) > 0 && dialect == "postgres" {
+			down = append(down, "DROP TYPE "+quoteIdent(dialect, enumTypeName(c.Field)))
+		}
+	}
+	return up, down
+}
+
+// alterTable renders the statements turning the columns of a table from before into after,
+// and those turning them back. Added and removed columns are added and dropped; a column whose
+// definition changed is left to the user, with a TODO comment describing the change.
+func alterTable(dialect, table string, before, after []tableColumn) (up, down []string) {
+	previous := map[string]tableColumn{}
+	for _, c := range before {
+		previous[c.Name] = c
+	}
+	current := map[string]bool{}
+	// Each change is reverted in the reverse order
+	var reverts [][]string
+	change := func(apply, revert []string) {
+		up = append(up, apply...)
+		reverts = append(reverts, revert)
+	}
+
+	for _, c := range after {
+		current[c.Name] = true
+		p, ok := previous[c.Name]
+		switch {
+		case !ok:
+			change(addColumn(dialect, table, c), dropColumn(dialect, table, c))
+		case columnDefinition(dialect, table, p.Field, p.Name, true) != columnDefinition(dialect, table, c.Field, c.Name, true) || p.References != c.References:
+			from, to := describeColumn(dialect, table, p), describeColumn(dialect, table, c)
+			change(
+				[]string{fmt.Sprintf("-- TODO: change the column %s from %s to %s", c.Name, from, to)},
+				[]string{fmt.Sprintf("-- TODO: change the column %s back from %s to %s", c.Name, to, from)},
+			)
+		case indexed(c) && !indexed(p):
+			change([]string{createIndex(dialect, table, c)}, []string{dropIndex(dialect, table, c)})
+		case !indexed(c) && indexed(p):
+			change([]string{dropIndex(dialect, table, c)}, []string{createIndex(dialect, table, c)})
+		}
+	}
+	for _, p := range before {
+		if !current[p.Name] {
+			change(dropColumn(dialect, table, p), addColumn(dialect, table, p))
+		}
+	}
+
+	for i := len(reverts) - 1; i >= 0; i-- {
+		down = append(down, reverts[i]...)
+	}
+	return up, down
+}
+
+// addColumn renders the statements adding a column to an existing table. Rows that exist
+// already need a value, so a NOT NULL column without a default gets the zero value of its type,
+// and a time the current one. A column with neither, like a foreign key, is added nullable,
+// with a TODO comment to fill it in and make it NOT NULL.
+func addColumn(dialect, table string, c tableColumn) []string {
+	var stmts []string
+	if len(c.Field.Enum) > 0 && dialect == "postgres" {
+		stmts = append(stmts, fmt.Sprintf("CREATE TYPE %s AS ENUM (%s)", quoteIdent(dialect, enumTypeName(c.Field)), sqlStrings(c.Field.Enum)))
+	}
+	def := columnDefinition(dialect, table, c.Field, c.Name, true)
+	// Postgres and MySQL fill the rows with the current time of a timestamp's default
+	timestamp := c.Field.Managed && (c.Field.Name == "CreatedAt" || c.Field.Name == "UpdatedAt") && dialect != "sqlite"
+	var fill []string
+	if notNull(c.Field) && !c.Field.HasDefault && !timestamp && c.Field.Name != "ID" {
+		zero := zeroDefault(c.Field)
+		switch {
+		case zero != "" && c.References == "":
+			def += " DEFAULT " + zero
+		case valueKind(c.Field) == "time" && dialect == "sqlite":
+			// SQLite only adds a NOT NULL column with a constant default, and cannot change it
+			// after, so the column keeps the zero time as its default
+			unmanaged := c.Field
+			unmanaged.Managed = false
+			def = columnDefinition(dialect, table, unmanaged, c.Name, true) + " DEFAULT '0001-01-01 00:00:00'"
+			fill = append(fill, fmt.Sprintf("UPDATE %s SET %s = CURRENT_TIMESTAMP", quoteIdent(dialect, table), quoteIdent(dialect, c.Name)))
+		default:
+			nullable := c.Field
+			nullable.Pointer = true
+			def = columnDefinition(dialect, table, nullable, c.Name, true)
+			tighten := setNotNull(dialect, table, c)
+			switch {
+			case valueKind(c.Field) == "time":
+				fill = append(fill, fmt.Sprintf("UPDATE %s SET %s = %s", quoteIdent(dialect, table), quoteIdent(dialect, c.Name), currentTimestamp(dialect)), tighten)
+			case tighten == "":
+				fill = append(fill, fmt.Sprintf("-- TODO: set %s on the rows that exist, SQLite only makes it NOT NULL by rebuilding the table", c.Name))
+			default:
+				fill = append(fill, fmt.Sprintf("-- TODO: set %s on the rows that exist, then make it NOT NULL: %s", c.Name, tighten))
+			}
+		}
+	}
+	ref := fmt.Sprintf("REFERENCES %s (%s)", quoteIdent(dialect, c.References), quoteIdent(dialect, "id"))
+	if c.References != "" && dialect != "mysql" {
+		def += " " + ref
+	}
+	stmts = append(stmts, fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s", quoteIdent(dialect, table), def))
+	stmts = append(stmts, fill...)
+	// MySQL ignores REFERENCES in a column definition
+	if c.References != "" && dialect == "mysql" {
+		stmts = append(stmts, fmt.Sprintf("ALTER TABLE %s ADD CONSTRAINT %s FOREIGN KEY (%s) %s",
+			quoteIdent(dialect, table), quoteIdent(dialect, foreignKeyName(table, c)), quoteIdent(dialect, c.Name), ref))
+	}
+	if indexed(c) {
+		stmts = append(stmts, createIndex(dialect, table, c))
+	}
+	return stmts
+}
+
+// setNotNull renders the statement making an added column NOT NULL, or "" on SQLite, which
+// cannot change a column.
+func setNotNull(dialect, table string, c tableColumn) string {
+	switch dialect {
+	case "postgres":
+		return fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s SET NOT NULL", quoteIdent(dialect, table), quoteIdent(dialect, c.Name))
+	case "mysql":
+		return fmt.Sprintf("ALTER TABLE %s MODIFY COLUMN %s", quoteIdent(dialect, table), columnDefinition(dialect, table, c.Field, c.Name, true))
+	}
+	return ""
+}
+
+// dropColumn renders the statements dropping a column, and its index first, which SQLite
+// requires.
+func dropColumn(dialect, table string, c tableColumn) []string {
+	var stmts []string
+	if indexed(c) {
+		stmts = append(stmts, dropIndex(dialect, table, c))
+	}
+	if c.References != "" && dialect == "mysql" {
+		stmts = append(stmts, fmt.Sprintf("-- The foreign key of %s must be dropped first, MySQL names it like %s_ibfk_1 when the table was created with it", c.Name, table))
+	}
+	stmts = append(stmts, fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s", quoteIdent(dialect, table), quoteIdent(dialect, c.Name)))
+	if len(c.Field.Enum) > 0 && dialect == "postgres" {
+		stmts = append(stmts, "DROP TYPE "+quoteIdent(dialect, enumTypeName(c.Field)))
+	}
+	return stmts
+}
+
+func foreignKeyName(table string, c tableColumn) string {
+	return "fk_" + table + "_" + c.Name
+}
+
+// describeColumn renders a column for a comment, with the table it refers to.
+func describeColumn(dialect, table string, c tableColumn) string {
+	def := columnDefinition(dialect, table, c.Field, c.Name, true)
+	if c.References != "" {
+		def += " REFERENCES " + quoteIdent(dialect, c.References)
+	}
+	return "`" + def + "`"
+}
+
+// zeroDefault renders the zero value of a field as a SQL literal, or "" when it has none.
+func zeroDefault(f Field) string {
+	switch valueKind(f) {
+	case "string":
+		return "''"
+	case "enum":
+		return sqlLiteral(f.Enum[0])
+	case "int", "float":
+		return "0"
+	case "bool":
+		return "FALSE"
+	}
+	return ""
+}
+
+// enumTypeName names the Postgres type of an enum field after its Go type, like post_status.
+func enumTypeName(f Field) string {
+	return inflect.Snake(strings.TrimPrefix(f.Type, "*"))
+}
+
+// columnDefinition renders a column of a CREATE TABLE statement. ownKey is set when the ID
+// field is the primary key on its own.
+func columnDefinition(dialect, table string, f Field, name string, ownKey bool) string {
+	typ := sqlColumnType(dialect, f)
+	def := quoteIdent(dialect, name) + " " + typ
+
+	if f.Name == "ID" && f.Relation == nil && ownKey {
+		if valueKind(f) == "int" {
+			switch dialect {
+			case "postgres":
+				if typ == "INTEGER" || typ == "SMALLINT" {
+					return quoteIdent(dialect, name) + " SERIAL PRIMARY KEY"
+				}
+				return quoteIdent(dialect, name) + " BIGSERIAL PRIMARY KEY"
+			case "mysql":
+				return def + " NOT NULL AUTO_INCREMENT PRIMARY KEY"
+			default:
+				// Only INTEGER PRIMARY KEY makes the rowid the key in SQLite
+				return quoteIdent(dialect, name) + " INTEGER PRIMARY KEY"
+			}
+		}
+		return def + " NOT NULL PRIMARY KEY"
+	}
+
+	if notNull(f) {
+		def += " NOT NULL"
+	}
+	if f.Unique {
+		def += " UNIQUE"
+	}
+	switch {
+	case f.HasDefault:
+		def += " DEFAULT " + sqlDefault(dialect, f)
+	case f.Managed && (f.Name == "CreatedAt" || f.Name == "UpdatedAt"):
+		def += " DEFAULT " + currentTimestamp(dialect)
+	}
+	if len(f.Enum) > 0 && dialect == "sqlite" {
+		def += fmt.Sprintf(" CHECK (%s IN (%s))", quoteIdent(dialect, name), sqlStrings(f.Enum))
+	}
+	return def
+}
+
+// notNull reports whether a field always holds a value, so that its column is NOT NULL.
+func notNull(f Field) bool {
+	base := strings.TrimPrefix(f.Type, "*")
+	return !f.Pointer && !f.Slice && !f.Map && base != "any" && base != "json.RawMessage" && !strings.HasPrefix(base, "sql.Null")
+}
+
+// sqlColumnType maps the Go type of a field to a column type of the dialect.
+func sqlColumnType(dialect string, f Field) string {
+	base := strings.TrimPrefix(f.Type, "*")
+	if len(f.Enum) > 0 {
+		switch dialect {
+		case "postgres":
+			return quoteIdent(dialect, enumTypeName(f))
+		case "mysql":
+			return "ENUM(" + sqlStrings(f.Enum) + ")"
+		}
+		return "TEXT"
+	}
+	// sql.NullString and the like hold the type they are named after
+	if null, ok := strings.CutPrefix(base, "sql.Null"); ok {
+		base = map[string]string{
+			"String": "string", "Int64": "int64", "Int32": "int32", "Int16": "int16", "Byte": "byte",
+			"Float64": "float64", "Bool": "bool", "Time": "time.Time",
+		}[null]
+	}
+
+	pick := func(postgres, mysql, sqlite string) string {
+		switch dialect {
+		case "postgres":
+			return postgres
+		case "mysql":
+			return mysql
+		}
+		return sqlite
+	}
+	switch base {
+	case "string":
+		length := "255"
+		if f.Max != "" {
+			if n, err := strconv.ParseFloat(f.Max, 64); err == nil && n <= 16383 {
+				length = strconv.Itoa(int(n))
+			} else {
+				return pick("TEXT", "TEXT", "TEXT")
+			}
+			return pick("VARCHAR("+length+")", "VARCHAR("+length+")", "TEXT")
+		}
+		return pick("TEXT", "VARCHAR("+length+")", "TEXT")
+	case "bool":
+		return "BOOLEAN"
+	case "int", "int64", "time.Duration":
+		return pick("BIGINT", "BIGINT", "INTEGER")
+	case "uint", "uint64":
+		return pick("BIGINT", "BIGINT UNSIGNED", "INTEGER")
+	case "int32", "rune":
+		return "INTEGER"
+	case "uint32":
+		return pick("BIGINT", "INT UNSIGNED", "INTEGER")
+	case "int8", "int16", "uint8", "uint16", "byte":
+		return pick("SMALLINT", "SMALLINT", "INTEGER")
+	case "float32":
+		return pick("REAL", "FLOAT", "REAL")
+	case "float64":
+		return pick("DOUBLE PRECISION", "DOUBLE", "REAL")
+	case "time.Time":
+		return pick("TIMESTAMPTZ", "DATETIME(6)", "DATETIME")
+	case "[]byte":
+		return pick("BYTEA", "BLOB", "BLOB")
+	case "uuid.UUID":
+		return pick("UUID", "CHAR(36)", "TEXT")
+	case "ulid.ULID":
+		// ULIDs are stored in their 16 bytes binary form, which is what ulid.ULID.Value returns
+		return pick("BYTEA", "BINARY(16)", "BLOB")
+	}
+	// Slices, maps and anything else are stored as JSON
+	return pick("JSONB", "JSON", "TEXT")
+}
+
+// sqlDefault renders the default value of a field as a SQL literal.
+func sqlDefault(dialect string, f Field) string {
+	value := f.Default
+	switch valueKind(f) {
+	case "int", "float":
+		if _, err := strconv.ParseFloat(value, 64); err == nil {
+			return value
+		}
+	case "time":
+		switch strings.ToLower(value) {
+		case "now", "now()", "current_timestamp":
+			return currentTimestamp(dialect)
+		}
+	}
+	if strings.TrimPrefix(f.Type, "*") == "bool" {
+		switch strings.ToLower(value) {
+		case "true", "1", "t":
+			return "TRUE"
+		case "false", "0", "f":
+			return "FALSE"
+		}
+	}
+	return sqlLiteral(value)
+}
+
+func currentTimestamp(dialect string) string {
+	if dialect == "mysql" {
+		// The default must have the precision of the DATETIME(6) column
+		return "CURRENT_TIMESTAMP(6)"
+	}
+	return "CURRENT_TIMESTAMP"
+}
+
+func sqlLiteral(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+func sqlStrings(values []string) string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = sqlLiteral(v)
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// sqlReserved are the words that must be quoted to name a table or a column.
+var sqlReserved = map[string]bool{
+	"all": true, "and": true, "as": true, "asc": true, "by": true, "case": true, "check": true,
+	"column": true, "constraint": true, "create": true, "default": true, "delete": true,
+	"desc": true, "distinct": true, "drop": true, "else": true, "end": true, "from": true,
+	"group": true, "having": true, "in": true, "index": true, "insert": true, "into": true,
+	"is": true, "join": true, "key": true, "limit": true, "not": true, "null": true, "offset": true,
+	"on": true, "or": true, "order": true, "primary": true, "references": true, "select": true,
+	"set": true, "table": true, "then": true, "to": true, "union": true, "unique": true,
+	"update": true, "user": true, "using": true, "values": true, "when": true, "where": true,
+}
+
+var plainIdent = regexp.MustCompile(`^[a-z_][a-z0-9_]*$`)
+
+// quoteIdent quotes a table or column name when the dialect would not read it as is.
+func quoteIdent(dialect, name string) string {
+	if plainIdent.MatchString(name) && !sqlReserved[name] {
+		return name
+	}
+	if dialect == "mysql" {
+		return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+	}
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// tableOf returns the table of a model recorded in the schema, or of the model being generated.
+// Embedded models have none.
+func tableOf(schema *Schema, model, current, currentTable string) (string, bool) {
+	if sameName(model, current) {
+		return currentTable, true
+	}
+	m := schema.Model(model)
+	if m == nil || m.Embedded {
+		return "", false
+	}
+	if m.Table != "" {
+		return m.Table, true
+	}
+	return inflect.NamesFor(m.Name).Table, true
+}
+
+// belongsToTables maps the foreign keys of a model to the tables they refer to. Foreign keys
+// are only declared to the tables of models gun knows about, whose migrations come first.
+func belongsToTables(schema *Schema, data modelData, table string) map[string]string {
+	references := map[string]string{}
+	for _, f := range data.Relations {
+		if r := f.Relation; r.Kind == BelongsTo {
+			if ref, ok := tableOf(schema, r.Model, data.ModelName, table); ok {
+				references[r.ForeignKey] = ref
+			}
+		}
+	}
+	return references
+}
+
+// previousColumns returns the columns of a model as the schema recorded them the last time it
+// was generated, or false for a model the schema does not know.
+func previousColumns(ctx *ProjectContext, schema *Schema, model, table string) ([]tableColumn, bool, error) {
+	m := schema.Model(model)
+	if m == nil {
+		return nil, false, nil
+	}
+	fields, err := m.ParsedFields()
+	if err != nil {
+		return nil, false, err
+	}
+	data, err := newModelData(ctx, model, resolveRelations(schema, model, withKey(fields)))
+	if err != nil {
+		return nil, false, err
+	}
+	return tableColumns(data.Fields, belongsToTables(schema, data, table)), true, nil
+}
+
+// writeModelMigrations writes the migrations creating the table of a model and of the join
+// models of its many_to_many relations. A table created by an earlier migration, or read from
+// the database, is altered by a new one instead, since it may exist already. Models read from
+// an existing schema, and embedded ones, get no migration.
+func writeModelMigrations(ctx *ProjectContext, schema *Schema, data modelData, joins map[string]modelData) error {
+	if data.Existing || data.Embedded {
+		return nil
+	}
+	dialect := strings.ToLower(ctx.Config.Dialect)
+	table := data.Table
+	if table == "" {
+		table = data.Names.Table
+	}
+	dir, err := MigrationsDir(ctx)
+	if err != nil {
+		return err
+	}
+
+	references := belongsToTables(schema, data, table)
+	_, exists, err := migrationVersion(dir, "create_"+table)
+	if err != nil {
+		return err
+	}
+	if m := schema.Model(data.ModelName); exists || m != nil && m.Existing {
+		if err := writeAlterMigration(ctx, dir, schema, data, table, references); err != nil {
+			return err
+		}
+	} else {
+		up, down := createTable(dialect, table, data.Fields, references, nil)
+		if err := writeMigration(ctx, "create_"+table, up, down); err != nil {
+			return err
+		}
+	}
+
+	for _, f := range data.Relations {
+		r := f.Relation
+		join, ok := joins[r.Join]
+		if r.Kind != ManyToMany || !ok {
+			continue
+		}
+		// The columns of a join table follow from the relation, so an existing one is kept
+		_, exists, err := migrationVersion(dir, "create_"+join.Names.Table)
+		if err != nil {
+			return err
+		}
+		if exists {
+			continue
+		}
+		references := map[string]string{r.ForeignKey: table}
+		if ref, ok := tableOf(schema, r.Model, data.ModelName, table); ok {
+			references[r.References] = ref
+		}
+		up, down := createTable(dialect, join.Names.Table, join.Fields, references, []string{r.ForeignKey, r.References})
+		if err := writeMigration(ctx, "create_"+join.Names.Table, up, down); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeAlterMigration writes the migration altering the table of a model generated again into
+// its new columns, like add_pages_to_books. Nothing is written when the columns are the same.
+func writeAlterMigration(ctx *ProjectContext, dir string, schema *Schema, data modelData, table string, references map[string]string) error {
+	before, ok, err := previousColumns(ctx, schema, data.ModelName, table)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		it.Warnf("The migration creating %s exists already and was kept, generate a migration for the changes to %s", table, data.ModelName)
+		return nil
+	}
+	after := tableColumns(data.Fields, references)
+	up, down := alterTable(strings.ToLower(ctx.Config.Dialect), table, before, after)
+	if len(up) == 0 {
+		return nil
+	}
+
+	name := "alter_" + table
+	// A migration adding a single column is named after it
+	if added := addedColumns(before, after); len(added) == 1 {
+		if rest, _ := alterTable("", table, append(slices.Clone(before), added[0]), after); len(rest) == 0 {
+			name = "add_" + added[0].Name + "_to_" + table
+		}
+	}
+	base := name
+	for n := 2; ; n++ {
+		_, exists, err := migrationVersion(dir, name)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			break
+		}
+		name = base + "_" + strconv.Itoa(n)
+	}
+	if slices.ContainsFunc(up, func(stmt string) bool { return strings.HasPrefix(stmt, "-- TODO") }) {
+		it.Warnf("Some columns of %s changed, fill in the TODOs of the %s migration", table, name)
+	}
+	return writeMigration(ctx, name, up, down)
+}
+
+// addedColumns returns the columns of after that before does not have.
+func addedColumns(before, after []tableColumn) []tableColumn {
+	var added []tableColumn
+	for _, c := range after {
+		if !slices.ContainsFunc(before, func(p tableColumn) bool { return p.Name == c.Name }) {
+			added = append(added, c)
+		}
+	}
+	return added
+}
@@ -0,0 +1,356 @@
+package generator
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/theHamdiz/gun/internal/config"
+	"github.com/theHamdiz/gun/internal/migrate"
+	_ "modernc.org/sqlite"
+)
+
+func TestAlterTable(t *testing.T) {
+	title := tableColumn{Field: Field{Name: "Title", Type: "string"}, Name: "title"}
+	pages := tableColumn{Field: Field{Name: "Pages", Type: "int"}, Name: "pages"}
+	genre := tableColumn{Field: Field{Name: "Genre", Type: "string", Index: true}, Name: "genre"}
+	status := tableColumn{Field: Field{Name: "Status", Type: "BookStatus", Enum: []string{"draft", "done"}}, Name: "status"}
+	author := tableColumn{Field: Field{Name: "AuthorID", Type: "int64", Index: true}, Name: "author_id", References: "authors"}
+	indexedTitle := title
+	indexedTitle.Field.Index = true
+	optionalTitle := title
+	createdAt := tableColumn{Field: Field{Name: "CreatedAt", Type: "time.Time", Managed: true}, Name: "created_at"}
+	publishedAt := tableColumn{Field: Field{Name: "PublishedAt", Type: "time.Time"}, Name: "published_at"}
+	optionalTitle.Field.Type, optionalTitle.Field.Pointer = "*string", true
+
+	tests := []struct {
+		name          string
+		dialect       string
+		before, after []tableColumn
+		up, down      []string
+	}{
+		{
+			name:    "unchanged",
+			dialect: "postgres",
+			before:  []tableColumn{title, pages},
+			after:   []tableColumn{pages, title},
+		},
+		{
+			name:    "added",
+			dialect: "sqlite",
+			before:  []tableColumn{title},
+			after:   []tableColumn{title, pages, genre},
+			up: []string{
+				"ALTER TABLE books ADD COLUMN pages INTEGER NOT NULL DEFAULT 0",
+				"ALTER TABLE books ADD COLUMN genre TEXT NOT NULL DEFAULT ''",
+				"CREATE INDEX idx_books_genre ON books (genre)",
+			},
+			down: []string{
+				"DROP INDEX idx_books_genre",
+				"ALTER TABLE books DROP COLUMN genre",
+				"ALTER TABLE books DROP COLUMN pages",
+			},
+		},
+		{
+			name:    "enum added and removed",
+			dialect: "postgres",
+			before:  []tableColumn{title},
+			after:   []tableColumn{status},
+			up: []string{
+				"CREATE TYPE book_status AS ENUM ('draft', 'done')",
+				"ALTER TABLE books ADD COLUMN status book_status NOT NULL DEFAULT 'draft'",
+				"ALTER TABLE books DROP COLUMN title",
+			},
+			down: []string{
+				"ALTER TABLE books ADD COLUMN title TEXT NOT NULL DEFAULT ''",
+				"ALTER TABLE books DROP COLUMN status",
+				"DROP TYPE book_status",
+			},
+		},
+		{
+			name:    "foreign key on MySQL",
+			dialect: "mysql",
+			after:   []tableColumn{author},
+			up: []string{
+				"ALTER TABLE books ADD COLUMN author_id BIGINT",
+				"-- TODO: set author_id on the rows that exist, then make it NOT NULL: ALTER TABLE books MODIFY COLUMN author_id BIGINT NOT NULL",
+				"ALTER TABLE books ADD CONSTRAINT fk_books_author_id FOREIGN KEY (author_id) REFERENCES authors (id)",
+				"CREATE INDEX idx_books_author_id ON books (author_id)",
+			},
+			down: []string{
+				"DROP INDEX idx_books_author_id ON books",
+				"-- The foreign key of author_id must be dropped first, MySQL names it like books_ibfk_1 when the table was created with it",
+				"ALTER TABLE books DROP COLUMN author_id",
+			},
+		},
+		{
+			name:    "foreign key",
+			dialect: "postgres",
+			after:   []tableColumn{author},
+			up: []string{
+				"ALTER TABLE books ADD COLUMN author_id BIGINT REFERENCES authors (id)",
+				"-- TODO: set author_id on the rows that exist, then make it NOT NULL: ALTER TABLE books ALTER COLUMN author_id SET NOT NULL",
+				"CREATE INDEX idx_books_author_id ON books (author_id)",
+			},
+			down: []string{
+				"DROP INDEX idx_books_author_id",
+				"ALTER TABLE books DROP COLUMN author_id",
+			},
+		},
+		{
+			name:    "foreign key on SQLite",
+			dialect: "sqlite",
+			after:   []tableColumn{author},
+			up: []string{
+				"ALTER TABLE books ADD COLUMN author_id INTEGER REFERENCES authors (id)",
+				"-- TODO: set author_id on the rows that exist, SQLite only makes it NOT NULL by rebuilding the table",
+				"CREATE INDEX idx_books_author_id ON books (author_id)",
+			},
+			down: []string{
+				"DROP INDEX idx_books_author_id",
+				"ALTER TABLE books DROP COLUMN author_id",
+			},
+		},
+		{
+			name:    "times",
+			dialect: "postgres",
+			after:   []tableColumn{createdAt, publishedAt},
+			up: []string{
+				"ALTER TABLE books ADD COLUMN created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP",
+				"ALTER TABLE books ADD COLUMN published_at TIMESTAMPTZ",
+				"UPDATE books SET published_at = CURRENT_TIMESTAMP",
+				"ALTER TABLE books ALTER COLUMN published_at SET NOT NULL",
+			},
+			down: []string{
+				"ALTER TABLE books DROP COLUMN published_at",
+				"ALTER TABLE books DROP COLUMN created_at",
+			},
+		},
+		{
+			name:    "times on SQLite",
+			dialect: "sqlite",
+			after:   []tableColumn{createdAt, publishedAt},
+			up: []string{
+				"ALTER TABLE books ADD COLUMN created_at DATETIME NOT NULL DEFAULT '0001-01-01 00:00:00'",
+				"UPDATE books SET created_at = CURRENT_TIMESTAMP",
+				"ALTER TABLE books ADD COLUMN published_at DATETIME NOT NULL DEFAULT '0001-01-01 00:00:00'",
+				"UPDATE books SET published_at = CURRENT_TIMESTAMP",
+			},
+			down: []string{
+				"ALTER TABLE books DROP COLUMN published_at",
+				"ALTER TABLE books DROP COLUMN created_at",
+			},
+		},
+		{
+			name:    "index",
+			dialect: "mysql",
+			before:  []tableColumn{title},
+			after:   []tableColumn{indexedTitle},
+			up:      []string{"CREATE INDEX idx_books_title ON books (title)"},
+			down:    []string{"DROP INDEX idx_books_title ON books"},
+		},
+		{
+			name:    "changed",
+			dialect: "postgres",
+			before:  []tableColumn{title},
+			after:   []tableColumn{optionalTitle},
+			up:      []string{"-- TODO: change the column title from `title TEXT NOT NULL` to `title TEXT`"},
+			down:    []string{"-- TODO: change the column title back from `title TEXT` to `title TEXT NOT NULL`"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			up, down := alterTable(tt.dialect, "books", tt.before, tt.after)
+			if !reflect.DeepEqual(up, tt.up) {
+				t.Errorf("up =\n%s\nwant\n%s", strings.Join(up, "\n"), strings.Join(tt.up, "\n"))
+			}
+			if !reflect.DeepEqual(down, tt.down) {
+				t.Errorf("down =\n%s\nwant\n%s", strings.Join(down, "\n"), strings.Join(tt.down, "\n"))
+			}
+		})
+	}
+}
+
+func TestGenerateModelAltersItsTable(t *testing.T) {
+	inTempDir(t)
+	writeTestFile(t, "go.mod", "module example.com/app\n\ngo 1.23\n")
+	ctx := &ProjectContext{ModuleName: "example.com/app", Config: config.Default()}
+	migrations := func() []string {
+		dir, err := MigrationsDir(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files, err := filepath.Glob(filepath.Join(dir, "*.up.sql"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return files
+	}
+
+	if err := GenerateModel(ctx, "Book", []Field{{Name: "Title", Type: "string"}}, ModelOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	created := migrations()
+	if len(created) != 1 || !strings.HasSuffix(created[0], "_create_books.up.sql") {
+		t.Fatalf("migrations = %v, want the one creating books", created)
+	}
+	original := readTestFile(t, created[0])
+
+	SetConflictPolicy(ConflictForce)
+	t.Cleanup(func() { SetConflictPolicy("") })
+	fields := []Field{{Name: "Title", Type: "string"}, {Name: "Pages", Type: "int"}}
+	for range 2 {
+		if err := GenerateModel(ctx, "Book", fields, ModelOptions{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := readTestFile(t, created[0]); got != original {
+		t.Errorf("the migration creating books was rewritten:\n%s", got)
+	}
+	all := migrations()
+	if len(all) != 2 || !strings.HasSuffix(all[1], "_add_pages_to_books.up.sql") {
+		t.Fatalf("migrations = %v, want one adding pages after the one creating books", all)
+	}
+	if got := readTestFile(t, all[1]); !strings.Contains(got, "ALTER TABLE books ADD COLUMN pages INTEGER NOT NULL DEFAULT 0;") {
+		t.Errorf("add_pages_to_books =\n%s", got)
+	}
+
+	if err := GenerateMigration(ctx, "add_pages_to_books"); err == nil {
+		t.Error("GenerateMigration() rewrote an existing migration")
+	}
+}
+
+func TestEmbeddedModelsHaveNoTable(t *testing.T) {
+	inTempDir(t)
+	writeTestFile(t, "go.mod", "module example.com/app\n\ngo 1.23\n")
+	ctx := &ProjectContext{ModuleName: "example.com/app", Config: config.Default()}
+	defs, err := ModelsFromJSON("user", []byte(`{"id": 1, "address": {"city": "Cairo"}, "tags": [{"name": "a"}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := GenerateModels(ctx, defs); err != nil {
+		t.Fatal(err)
+	}
+	dir, err := MigrationsDir(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, err := filepath.Glob(filepath.Join(dir, "*.up.sql"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 || !strings.HasSuffix(files[0], "_create_users.up.sql") {
+		t.Errorf("migrations = %v, want only the one creating users", files)
+	}
+}
+
+func TestAddedColumnsMigrateTableWithRows(t *testing.T) {
+	inTempDir(t)
+	writeTestFile(t, "go.mod", "module example.com/app\n\ngo 1.23\n")
+	ctx := &ProjectContext{ModuleName: "example.com/app", Config: config.Default()}
+	db, err := sql.Open("sqlite", "file:"+filepath.Join(t.TempDir(), "app.db")+"?_pragma=foreign_keys(1)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	migrateDB := func() {
+		t.Helper()
+		dir, err := MigrationsDir(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		migrations, err := migrate.Load(os.DirFS(dir), ".")
+		if err != nil {
+			t.Fatal(err)
+		}
+		m, err := migrate.New(db, "sqlite", migrations)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := m.Migrate(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	withID := ModelOptions{ID: "int"}
+	if err := GenerateModel(ctx, "Author", []Field{{Name: "Name", Type: "string"}}, withID); err != nil {
+		t.Fatal(err)
+	}
+	if err := GenerateModel(ctx, "Book", []Field{{Name: "Title", Type: "string"}}, withID); err != nil {
+		t.Fatal(err)
+	}
+	migrateDB()
+	if _, err := db.Exec("INSERT INTO books (title) VALUES ('Dune')"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Every added column is NOT NULL, and only some have a zero value SQLite takes as a default
+	SetConflictPolicy(ConflictForce)
+	t.Cleanup(func() { SetConflictPolicy("") })
+	fields, err := ParseFields("Title:string Pages:int PublishedAt:time.Time Author:belongs_to:Author")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := GenerateModel(ctx, "Book", fields, ModelOptions{ID: "int", Timestamps: true}); err != nil {
+		t.Fatal(err)
+	}
+	migrateDB()
+
+	var pages int
+	var publishedAt, createdAt string
+	var authorID sql.NullInt64
+	if err := db.QueryRow("SELECT pages, published_at, created_at, author_id FROM books").Scan(&pages, &publishedAt, &createdAt, &authorID); err != nil {
+		t.Fatal(err)
+	}
+	if pages != 0 || strings.HasPrefix(publishedAt, "0001") || strings.HasPrefix(createdAt, "0001") || authorID.Valid {
+		t.Errorf("the book has pages %d, published_at %q, created_at %q and author_id %v, want 0, the current time twice and no author", pages, publishedAt, createdAt, authorID)
+	}
+}
+
+func TestWriteMigratorCopiesMigrate(t *testing.T) {
+	inTempDir(t)
+	ctx := &ProjectContext{ModuleName: "example.com/app", Config: config.Default()}
+	if err := writeMigrator(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got := readTestFile(t, "internal/db/migrate/migrate.go"); got != migrate.Source {
+		t.Error("internal/db/migrate/migrate.go differs from the migrator of gun db")
+	}
+	if got := readTestFile(t, "internal/db/migrate/migrate_test.go"); got != migrate.TestSource {
+		t.Error("internal/db/migrate/migrate_test.go differs from the tests of the migrator of gun db")
+	}
+	if got := readTestFile(t, "internal/db/migrate.go"); !strings.Contains(got, `"example.com/app/internal/db/migrate"`) {
+		t.Errorf("internal/db/migrate.go does not use the copy of the migrator:\n%s", got)
+	}
+	if got := readTestFile(t, "internal/db/open.go"); !strings.Contains(got, `_ "modernc.org/sqlite"`) {
+		t.Errorf("internal/db/open.go does not register the SQLite driver:\n%s", got)
+	}
+	if got := readTestFile(t, "cmd/migrate/main.go"); !strings.Contains(got, "db.Migrator(conn)") {
+		t.Errorf("cmd/migrate/main.go does not run the migrations of the app:\n%s", got)
+	}
+}
+
+func TestWriteMigratorRegistersTheDriverOfTheDialect(t *testing.T) {
+	inTempDir(t)
+	ctx := &ProjectContext{ModuleName: "example.com/app", Config: config.Default()}
+	ctx.Config.Dialect = "postgres"
+	if err := writeMigrator(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got := readTestFile(t, "internal/db/open.go"); !strings.Contains(got, `_ "github.com/jackc/pgx/v5/stdlib"`) || strings.Contains(got, "sqlite") {
+		t.Errorf("internal/db/open.go does not register the Postgres driver alone:\n%s", got)
+	}
+	// The tests running on SQLite stay out of projects using another database
+	if _, err := os.Stat("internal/db/migrate/migrate_test.go"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("internal/db/migrate/migrate_test.go was written for a Postgres project: %v", err)
+	}
+	if got := readTestFile(t, "internal/db/migrate_test.go"); strings.Contains(got, "sqlite") {
+		t.Errorf("internal/db/migrate_test.go uses SQLite in a Postgres project:\n%s", got)
+	}
+}
@@ -2,29 +2,168 @@ package generator
 
 import (
 	"fmt"
-	"os"
-	"path/filepath"
+	"sort"
+
+	"github.com/theHamdiz/gun/internal/inflect"
 )
 
-type Field struct {
-	Name string
-	Type string
+type modelData struct {
+	*ProjectContext
+	ModelName string
+	Names     inflect.Names
+	// Fields are the fields of the struct: columns first, then relationships.
+	Fields []Field
+	// Relations are the relationship fields, for the eager loading helpers.
+	Relations []Field
+	KeyType   string
+	// Table is set when the model is stored in a table not named after it, like tbl_user.
+	Table string
+	// HasChildren is set when related models refer to this one by its key.
+	HasChildren bool
+	// Options are the conventions of the model, like soft deletion.
+	Options ModelOptions
+	// Existing and Embedded are those of ModelDefinition: no migration creates their table.
+	Existing, Embedded bool
+	// Enums are the types of the enum fields.
+	Enums []EnumType
+	// Validations are the checks of the Validate method, and ValidationTest its test when
+	// HasValidationTest is set.
+	Validations       []Validation
+	ValidationTest    ValidationTest
+	HasValidationTest bool
+}
+
+func GenerateModel(ctx *ProjectContext, name string, fields []Field, opts ModelOptions) error {
+	return generateModel(ctx, ModelDefinition{Name: name, Fields: fields, Options: opts})
 }
 
-func GenerateModel(name string, fields []Field) error {
-	data := struct {
-		ModelName string
-		Fields    []Field
-	}{
-		ModelName: name,
-		Fields:    fields,
+// GenerateModels generates models read from an existing schema, one after the other, so that
+// each sees the key types of the models generated before it.
+func GenerateModels(ctx *ProjectContext, defs []ModelDefinition) error {
+	for _, def := range defs {
+		if err := generateModel(ctx, def); err != nil {
+			return fmt.Errorf("model %s: %w", def.Name, err)
+		}
+	}
+	return nil
+}
+
+func generateModel(ctx *ProjectContext, def ModelDefinition) error {
+	schema, err := LoadSchema()
+	if err != nil {
+		return err
+	}
+	// The struct is named in PascalCase however the model was written, like blog_post
+	model := inflect.Pascal(def.Name)
+	if err := def.Options.Check(); err != nil {
+		return err
+	}
+	fields, err := def.Options.withFields(def.Fields)
+	if err != nil {
+		return err
 	}
+	fields = resolveRelations(schema, model, withKey(fields))
 
-	modelFilePath := filepath.Join("internal", "models", fmt.Sprintf("%s.go", ToSnakeCase(name)))
-	err := os.MkdirAll(filepath.Dir(modelFilePath), 0755)
+	data, err := newModelData(ctx, model, fields)
 	if err != nil {
 		return err
 	}
+	data.Options = def.Options
+	data.Existing, data.Embedded = def.Existing, def.Embedded
+	if def.Table != inflect.NamesFor(model).Table {
+		data.Table = def.Table
+	}
+	joins := joinModels(fields)
+	joinNames := make([]string, 0, len(joins))
+	for join := range joins {
+		joinNames = append(joinNames, join)
+	}
+	sort.Strings(joinNames)
+
+	// The validation errors are shared by every model, so they are not tracked with this one
+	if err := CreateFileFromLayout("", "validation", data); err != nil {
+		return err
+	}
+	if err := writeMigrator(ctx); err != nil {
+		return err
+	}
+	return track("model", def.Name, func() error {
+		if err := CreateFileFromLayout("", "model", data); err != nil {
+			return err
+		}
+		if data.HasValidationTest {
+			if err := CreateFileFromLayout("", "model_test", data); err != nil {
+				return err
+			}
+		}
+		if len(data.Relations) > 0 {
+			if err := CreateFileFromLayout("", "relations", data); err != nil {
+				return err
+			}
+		}
+		// many_to_many relationships get a model for their join table
+		joinData := map[string]modelData{}
+		for _, join := range joinNames {
+			jd, err := newModelData(ctx, join, joins[join])
+			if err != nil {
+				return err
+			}
+			joinData[join] = jd
+			if err := CreateFileFromLayout("", "model", jd); err != nil {
+				return err
+			}
+			if jd.HasValidationTest {
+				if err := CreateFileFromLayout("", "model_test", jd); err != nil {
+					return err
+				}
+			}
+		}
+		if err := writeModelMigrations(ctx, schema, data, joinData); err != nil {
+			return err
+		}
 
-	return CreateFileFromTemplate(modelFilePath, ModelTemplate, data)
+		if IsDryRun() {
+			return nil
+		}
+		existing := def.Existing || schema.Model(model) != nil && schema.Model(model).Existing
+		schema.Put(model, fields)
+		schema.Model(model).Table = data.Table
+		schema.Model(model).ModelOptions = def.Options
+		schema.Model(model).Existing, schema.Model(model).Embedded = existing, def.Embedded
+		for _, join := range joinNames {
+			schema.Put(join, joins[join])
+		}
+		return schema.Save()
+	})
+}
+
+func newModelData(ctx *ProjectContext, name string, fields []Field) (modelData, error) {
+	// Struct tags follow the project's tag settings, unless a field sets them itself
+	all := modelFields(fields)
+	for i, f := range all {
+		tag, err := StructTag(ctx.Config.Tags, f)
+		if err != nil {
+			return modelData{}, err
+		}
+		all[i].Tag = tag
+	}
+
+	enums := enumTypes(name, all)
+	data := modelData{
+		ProjectContext: ctx,
+		ModelName:      name,
+		Names:          inflect.NamesFor(name),
+		Fields:         all,
+		Relations:      relations(fields),
+		KeyType:        keyTypeOf(modelColumns(fields)),
+		Enums:          enums,
+		Validations:    validations(all),
+	}
+	data.ValidationTest, data.HasValidationTest = validationTest(all)
+	for _, r := range data.Relations {
+		if r.Relation.Kind != BelongsTo {
+			data.HasChildren = true
+		}
+	}
+	return data, nil
 }
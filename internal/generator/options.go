@@ -0,0 +1,100 @@
+package generator
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// IDStrategies are the primary keys gun generate model --id generates.
+var IDStrategies = []string{"int", "uuid", "ulid"}
+
+// ModelOptions are the conventions a model follows beyond its fields, chosen with the flags of
+// gun generate model and recorded in the schema next to the fields.
+type ModelOptions struct {
+	// ID is the primary key strategy, one of IDStrategies, when gun generates the key.
+	ID string `json:"id,omitempty"`
+	// Timestamps adds CreatedAt and UpdatedAt, which the data layer maintains.
+	Timestamps bool `json:"timestamps,omitempty"`
+	// SoftDelete adds DeletedAt: deleting a record sets it, and queries leave such records out.
+	SoftDelete bool `json:"soft_delete,omitempty"`
+}
+
+// Check reports an unknown ID strategy.
+func (o ModelOptions) Check() error {
+	if o.ID != "" && !slices.Contains(IDStrategies, o.ID) {
+		return fmt.Errorf("unknown id strategy %q (expected %s)", o.ID, strings.Join(IDStrategies, ", "))
+	}
+	return nil
+}
+
+// KeyType is the Go type of the generated key, or "" when the model declares its own.
+func (o ModelOptions) KeyType() string {
+	switch o.ID {
+	case "uuid":
+		return "uuid.UUID"
+	case "ulid":
+		return "ulid.ULID"
+	case "int":
+		return "int"
+	}
+	return ""
+}
+
+// managedFields returns the fields the options add: the key, then the timestamps.
+func (o ModelOptions) managedFields() []Field {
+	var fields []Field
+	add := func(name, typ string, optional bool) {
+		f := Field{Name: name, Managed: true}
+		_ = f.parseType(fieldToken{text: typ})
+		if optional {
+			f.makeOptional()
+		}
+		fields = append(fields, f)
+	}
+	if typ := o.KeyType(); typ != "" {
+		add("ID", typ, false)
+	}
+	if o.Timestamps {
+		add("CreatedAt", "time.Time", false)
+		add("UpdatedAt", "time.Time", false)
+	}
+	if o.SoftDelete {
+		add("DeletedAt", "time.Time", true)
+		// Every query filters on it
+		fields[len(fields)-1].Index = true
+	}
+	return fields
+}
+
+// withFields adds the managed fields around the fields of a model: the key first, the
+// timestamps last. A field declared by hand under one of their names is an error.
+func (o ModelOptions) withFields(fields []Field) ([]Field, error) {
+	managed := o.managedFields()
+	if len(managed) == 0 {
+		return fields, nil
+	}
+	for _, m := range managed {
+		if hasField(fields, m.Name) {
+			return nil, fmt.Errorf("field %s is generated by the model options, remove it from the fields", m.Name)
+		}
+	}
+	all := make([]Field, 0, len(fields)+len(managed))
+	if managed[0].Name == "ID" {
+		all = append(all, managed[0])
+		managed = managed[1:]
+	}
+	all = append(all, fields...)
+	return append(all, managed...), nil
+}
+
+// unmanaged drops the fields added by the options, which the schema does not record.
+func unmanaged(fields []Field) []Field {
+	var out []Field
+	for _, f := range fields {
+		if !f.Managed {
+			out = append(out, f)
+		}
+	}
+	return out
+}
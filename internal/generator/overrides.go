@@ -0,0 +1,129 @@
+package generator
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// TemplateSource tells where the content of a template came from.
+type TemplateSource string
+
+const (
+	SourceProject TemplateSource = "project"
+	SourceUser    TemplateSource = "user"
+	SourceBuiltin TemplateSource = "built-in"
+)
+
+// ProjectTemplatesDir is where a project overrides templates, relative to its root.
+var ProjectTemplatesDir = filepath.Join(".gun", "templates")
+
+var (
+	templateDirsMu     sync.Mutex
+	projectTemplateDir string
+)
+
+// UseProjectTemplates makes templates in root/.gun/templates take precedence over the
+// user-level and built-in ones. It is not set for commands that run outside a project.
+func UseProjectTemplates(root string) {
+	templateDirsMu.Lock()
+	defer templateDirsMu.Unlock()
+	projectTemplateDir = filepath.Join(root, ProjectTemplatesDir)
+}
+
+// UserTemplatesDir is where a user overrides templates for every project, e.g. ~/.config/gun/templates.
+func UserTemplatesDir() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "gun", "templates"), nil
+}
+
+// TemplateNames returns the logical names of all templates of the active pack, sorted.
+func TemplateNames() []string {
+	return ActivePack().TemplateNames()
+}
+
+// BuiltinTemplate returns the content a template has in the active pack when nobody overrides it.
+func BuiltinTemplate(name string) (string, error) {
+	return ActivePack().Template(name)
+}
+
+// TemplateFile returns the path of the override for name inside a templates directory.
+func TemplateFile(dir, name string) string {
+	return filepath.Join(dir, filepath.FromSlash(name)+".tmpl")
+}
+
+// LookupTemplate resolves a template by its logical name: the project's .gun/templates/<name>.tmpl
+// wins over the user-level one, which wins over the built-in template.
+// It returns the content and the path it was read from, which is empty for built-in templates.
+func LookupTemplate(name string) (string, string, error) {
+	content, _, path, err := resolveTemplate(name)
+	return content, path, err
+}
+
+// TemplateOrigin reports which source a template is currently resolved from, and its path if any.
+func TemplateOrigin(name string) (TemplateSource, string, error) {
+	_, source, path, err := resolveTemplate(name)
+	return source, path, err
+}
+
+type templateDir struct {
+	source TemplateSource
+	path   string
+}
+
+func resolveTemplate(name string) (string, TemplateSource, string, error) {
+	for _, dir := range templateDirs() {
+		path := TemplateFile(dir.path, name)
+		content, err := os.ReadFile(path)
+		if err == nil {
+			return string(content), dir.source, path, nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return "", "", "", err
+		}
+	}
+
+	content, err := BuiltinTemplate(name)
+	return content, SourceBuiltin, "", err
+}
+
+func templateDirs() []templateDir {
+	templateDirsMu.Lock()
+	defer templateDirsMu.Unlock()
+
+	var dirs []templateDir
+	if projectTemplateDir != "" {
+		dirs = append(dirs, templateDir{source: SourceProject, path: projectTemplateDir})
+	}
+	if userDir, err := UserTemplatesDir(); err == nil {
+		dirs = append(dirs, templateDir{source: SourceUser, path: userDir})
+	}
+	return dirs
+}
+
+// EjectTemplates writes the built-in content of the named templates into dir, ready to be edited.
+// Every template is ejected when no name is given.
+func EjectTemplates(dir string, names ...string) ([]string, error) {
+	if len(names) == 0 {
+		names = TemplateNames()
+	}
+
+	var paths []string
+	for _, name := range names {
+		content, err := BuiltinTemplate(name)
+		if err != nil {
+			return nil, err
+		}
+		path := TemplateFile(dir, name)
+		if err := WriteFile(path, []byte(content)); err != nil {
+			return nil, err
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
@@ -0,0 +1,160 @@
+package generator
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+)
+
+// packsFS holds the built-in templates, one directory per template pack.
+//
+//go:embed packs
+var packsFS embed.FS
+
+// DefaultPack is the pack used when a project does not choose one.
+const DefaultPack = "fiber"
+
+// Pack is a set of templates for one stack, together with the layout of the files they generate.
+// A pack may extend another one, inheriting every template and layout entry it does not redefine.
+type Pack struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Extends     string `json:"extends,omitempty"`
+	// Dirs are created when a project is scaffolded with this pack.
+	Dirs []string `json:"dirs,omitempty"`
+	// Files maps a logical template name to the path it is generated at, itself a template.
+	Files map[string]string `json:"files"`
+
+	parent *Pack
+}
+
+var (
+	packMu     sync.Mutex
+	activePack *Pack
+)
+
+// LoadPack reads a built-in pack and the packs it extends.
+func LoadPack(name string) (*Pack, error) {
+	data, err := fs.ReadFile(packsFS, path.Join("packs", name, "pack.json"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("unknown template pack %q", name)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Pack{}
+	if err := json.Unmarshal(data, p); err != nil {
+		return nil, fmt.Errorf("invalid pack.json for pack %q: %w", name, err)
+	}
+	p.Name = name
+
+	if p.Extends != "" {
+		p.parent, err = LoadPack(p.Extends)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+// UsePack selects the pack every following generator renders from.
+func UsePack(name string) error {
+	p, err := LoadPack(name)
+	if err != nil {
+		return err
+	}
+
+	packMu.Lock()
+	defer packMu.Unlock()
+	activePack = p
+	return nil
+}
+
+// ActivePack returns the selected pack, loading the default one on first use.
+func ActivePack() *Pack {
+	packMu.Lock()
+	defer packMu.Unlock()
+	if activePack == nil {
+		p, err := LoadPack(DefaultPack)
+		if err != nil {
+			panic(err)
+		}
+		activePack = p
+	}
+	return activePack
+}
+
+// Template returns the content of a template, looking into the packs this one extends when needed.
+func (p *Pack) Template(name string) (string, error) {
+	for pack := p; pack != nil; pack = pack.parent {
+		data, err := fs.ReadFile(packsFS, path.Join("packs", pack.Name, name+".tmpl"))
+		if err == nil {
+			return string(data), nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return "", err
+		}
+	}
+	return "", fmt.Errorf("unknown template %q in pack %q", name, p.Name)
+}
+
+// TemplateNames returns the logical names of every template available in the pack, sorted.
+func (p *Pack) TemplateNames() []string {
+	seen := map[string]bool{}
+	for pack := p; pack != nil; pack = pack.parent {
+		root := path.Join("packs", pack.Name)
+		_ = fs.WalkDir(packsFS, root, func(file string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() || !strings.HasSuffix(file, ".tmpl") {
+				return err
+			}
+			seen[strings.TrimSuffix(strings.TrimPrefix(file, root+"/"), ".tmpl")] = true
+			return nil
+		})
+	}
+
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Directories returns the directories a project scaffolded with this pack starts with.
+func (p *Pack) Directories() []string {
+	var dirs []string
+	if p.parent != nil {
+		dirs = p.parent.Directories()
+	}
+	return append(dirs, p.Dirs...)
+}
+
+// Destination renders the path the pack's layout declares for a template.
+func (p *Pack) Destination(name string, data any) (string, error) {
+	for pack := p; pack != nil; pack = pack.parent {
+		layout, ok := pack.Files[name]
+		if !ok {
+			continue
+		}
+
+		tmpl, err := template.New(name + " layout").Funcs(templateFuncs()).Parse(layout)
+		if err != nil {
+			return "", err
+		}
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, data); err != nil {
+			return "", &TemplateError{Template: name + " layout", Destination: layout, Data: data, Err: err}
+		}
+		return path.Clean(buf.String()), nil
+	}
+	return "", fmt.Errorf("pack %q does not declare where template %q is generated", p.Name, name)
+}
@@ -0,0 +1,262 @@
+package generator
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/theHamdiz/gun/internal/diff"
+	"github.com/theHamdiz/it"
+)
+
+// Action describes what a planned change does to a file.
+type Action string
+
+const (
+	ActionCreate    Action = "create"
+	ActionModify    Action = "modify"
+	ActionUnchanged Action = "identical"
+	ActionSkip      Action = "skip"
+	ActionMerge     Action = "merge"
+	ActionConflict  Action = "conflict"
+	ActionDelete    Action = "delete"
+)
+
+// Change is a single file write recorded by the planning layer.
+type Change struct {
+	Path   string
+	Action Action
+	Before []byte
+	After  []byte
+	// Dirs lists the directories that had to be created for this file.
+	Dirs []string
+	// Backup is set for the *.orig copies made by the backup conflict policy.
+	Backup bool
+	// Registration is set when the change only injects or removes a call in a file
+	// the generator does not own.
+	Registration *Registration
+}
+
+// Plan collects every write and command issued by the generators.
+// In dry-run mode nothing reaches the disk, the plan is only printed.
+type Plan struct {
+	mu       sync.Mutex
+	dryRun   bool
+	policy   ConflictPolicy
+	changes  []Change
+	commands []string
+}
+
+var plan = &Plan{}
+
+// SetDryRun toggles dry-run mode for all generators.
+func SetDryRun(dryRun bool) {
+	plan.mu.Lock()
+	defer plan.mu.Unlock()
+	plan.dryRun = dryRun
+}
+
+// IsDryRun reports whether generators are only planning their writes.
+func IsDryRun() bool {
+	plan.mu.Lock()
+	defer plan.mu.Unlock()
+	return plan.dryRun
+}
+
+// WriteFile is the single place generators write through.
+// It records the change, resolves conflicts with existing files according to
+// the conflict policy and, unless in dry-run mode, writes the result to disk.
+func WriteFile(path string, content []byte) error {
+	before, err := os.ReadFile(path)
+	exists := err == nil
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	if !exists {
+		return write(Change{Path: path, Action: ActionCreate, After: content})
+	}
+	if string(before) == string(content) {
+		plan.record(Change{Path: path, Action: ActionUnchanged, Before: before, After: content})
+		return nil
+	}
+
+	res, err := resolveConflict(path, before, content)
+	if err != nil {
+		return err
+	}
+
+	switch res.action {
+	case ActionSkip, ActionConflict:
+		plan.record(Change{Path: path, Action: res.action, Before: before, After: res.content})
+		if res.action == ActionSkip && !IsDryRun() {
+			it.Warnf("Skipped %s, it already exists", path)
+		}
+		return nil
+	}
+
+	if res.backup {
+		backup := Change{Path: path + ".orig", Action: ActionCreate, After: before, Backup: true}
+		if previous, err := os.ReadFile(backup.Path); err == nil {
+			backup.Action, backup.Before = ActionModify, previous
+		}
+		if err := write(backup); err != nil {
+			return err
+		}
+	}
+	return write(Change{Path: path, Action: res.action, Before: before, After: res.content})
+}
+
+func write(change Change) error {
+	change.Dirs = missingDirs(filepath.Dir(change.Path))
+	plan.record(change)
+	if IsDryRun() {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(change.Path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(change.Path, change.After, 0644)
+}
+
+// missingDirs returns dir and its ancestors that do not exist yet, deepest first.
+func missingDirs(dir string) []string {
+	var dirs []string
+	for dir != "." && dir != string(filepath.Separator) {
+		if _, err := os.Stat(dir); err == nil {
+			break
+		}
+		dirs = append(dirs, dir)
+		dir = filepath.Dir(dir)
+	}
+	return dirs
+}
+
+// RemoveFile deletes a file, or only records its deletion in dry-run mode.
+func RemoveFile(path string) error {
+	before, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	plan.record(Change{Path: path, Action: ActionDelete, Before: before})
+	if IsDryRun() {
+		return nil
+	}
+	return os.Remove(path)
+}
+
+// RemoveEmptyDir deletes a directory if it exists and is empty. Nothing happens in dry-run mode.
+func RemoveEmptyDir(path string) error {
+	if IsDryRun() {
+		return nil
+	}
+	entries, err := os.ReadDir(path)
+	if err != nil || len(entries) > 0 {
+		return nil
+	}
+	return os.Remove(path)
+}
+
+// MkdirAll creates a directory unless in dry-run mode.
+func MkdirAll(path string) error {
+	if IsDryRun() {
+		return nil
+	}
+	return os.MkdirAll(path, 0755)
+}
+
+// RunCommand runs an external command, or only records it in dry-run mode.
+func RunCommand(cmd *exec.Cmd) error {
+	line := strings.Join(cmd.Args, " ")
+	if cmd.Dir != "" {
+		line = fmt.Sprintf("%s (in %s)", line, cmd.Dir)
+	}
+
+	plan.mu.Lock()
+	plan.commands = append(plan.commands, line)
+	dryRun := plan.dryRun
+	plan.mu.Unlock()
+
+	if dryRun {
+		return nil
+	}
+	return cmd.Run()
+}
+
+// Changes returns the changes recorded so far, sorted by path.
+func Changes() []Change {
+	plan.mu.Lock()
+	defer plan.mu.Unlock()
+
+	changes := make([]Change, len(plan.changes))
+	copy(changes, plan.changes)
+	sort.SliceStable(changes, func(i, j int) bool {
+		return changes[i].Path < changes[j].Path
+	})
+	return changes
+}
+
+// PrintPlan writes the list of planned changes followed by a unified diff for each of them.
+func PrintPlan(w io.Writer) {
+	changes := Changes()
+
+	plan.mu.Lock()
+	commands := append([]string(nil), plan.commands...)
+	plan.mu.Unlock()
+
+	_, _ = fmt.Fprintln(w, "Dry run: no files were written.")
+	_, _ = fmt.Fprintln(w)
+	if len(changes) == 0 && len(commands) == 0 {
+		_, _ = fmt.Fprintln(w, "Nothing to do.")
+		return
+	}
+
+	for _, c := range changes {
+		_, _ = fmt.Fprintf(w, "  %-9s %s\n", c.Action, filepath.ToSlash(c.Path))
+	}
+	for _, command := range commands {
+		_, _ = fmt.Fprintf(w, "  %-9s %s\n", "run", command)
+	}
+
+	for _, c := range changes {
+		from, to := "a/"+filepath.ToSlash(c.Path), "b/"+filepath.ToSlash(c.Path)
+		switch c.Action {
+		case ActionCreate:
+			from = "/dev/null"
+		case ActionDelete:
+			to = "/dev/null"
+		}
+		patch := diff.Unified(from, to, string(c.Before), string(c.After))
+		if patch == "" {
+			continue
+		}
+		_, _ = fmt.Fprintln(w)
+		_, _ = fmt.Fprint(w, patch)
+	}
+}
+
+func (p *Plan) record(change Change) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.changes = append(p.changes, change)
+}
+
+// mark returns a position in the plan that since can later collect changes from.
+func (p *Plan) mark() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.changes)
+}
+
+func (p *Plan) since(mark int) []Change {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]Change(nil), p.changes[mark:]...)
+}
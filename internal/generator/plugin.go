@@ -0,0 +1,171 @@
+package generator
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/theHamdiz/it"
+)
+
+// PluginPrefix is the prefix of every plugin executable: `gun-event` provides `gun generate event`.
+const PluginPrefix = "gun-"
+
+// PluginsDir holds project-local plugins, relative to the project. They take precedence over PATH.
+var PluginsDir = filepath.Join(".gun", "plugins")
+
+// Plugin is an external generator executable.
+type Plugin struct {
+	Name string
+	Path string
+}
+
+// PluginRequest is written as JSON to the plugin's stdin.
+type PluginRequest struct {
+	Plugin  string          `json:"plugin"`
+	Args    []string        `json:"args"`
+	DryRun  bool            `json:"dry_run"`
+	Project *ProjectContext `json:"project"`
+}
+
+// PluginResponse is what a plugin prints as JSON on stdout: the files gun should write for it.
+type PluginResponse struct {
+	Files    []PluginFile `json:"files"`
+	Messages []string     `json:"messages,omitempty"`
+}
+
+// PluginFile is a file returned by a plugin. Its path is relative to the project root.
+type PluginFile struct {
+	Path    string `json:"path"`
+	Content string `json:"content"`
+}
+
+// FindPlugins lists the plugins available to a project: executables named gun-<name> in the
+// project's .gun/plugins directory and on PATH. The first plugin found for a name wins.
+// root may be empty when gun runs outside a project.
+func FindPlugins(root string) []Plugin {
+	var dirs []string
+	if root != "" {
+		dirs = append(dirs, filepath.Join(root, PluginsDir))
+	}
+	dirs = append(dirs, filepath.SplitList(os.Getenv("PATH"))...)
+
+	seen := map[string]bool{}
+	var plugins []Plugin
+	for _, dir := range dirs {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			name, ok := pluginName(entry)
+			if !ok || seen[name] {
+				continue
+			}
+			seen[name] = true
+			plugins = append(plugins, Plugin{Name: name, Path: filepath.Join(dir, entry.Name())})
+		}
+	}
+	sort.Slice(plugins, func(i, j int) bool { return plugins[i].Name < plugins[j].Name })
+	return plugins
+}
+
+// pluginName returns the generator name of a plugin executable, if entry is one.
+func pluginName(entry fs.DirEntry) (string, bool) {
+	if entry.IsDir() || !strings.HasPrefix(entry.Name(), PluginPrefix) {
+		return "", false
+	}
+	info, err := entry.Info()
+	if err != nil {
+		return "", false
+	}
+	name := strings.TrimPrefix(entry.Name(), PluginPrefix)
+	if runtime.GOOS == "windows" {
+		ext := filepath.Ext(name)
+		if !strings.EqualFold(ext, ".exe") {
+			return "", false
+		}
+		name = strings.TrimSuffix(name, ext)
+	} else if info.Mode().Perm()&0111 == 0 {
+		return "", false
+	}
+	return name, name != ""
+}
+
+// RunPlugin runs a plugin with the project context and args, and writes the files it returns
+// through the planning layer, so they get the same formatting, dry-run and conflict handling
+// as built-in generators. The files are recorded in the manifest under the plugin's name.
+func RunPlugin(ctx *ProjectContext, p Plugin, args []string) error {
+	res, err := callPlugin(ctx, p, args)
+	if err != nil {
+		return err
+	}
+	for _, msg := range res.Messages {
+		it.Info(msg)
+	}
+
+	// The first argument names the resource, as it does for built-in generators
+	name := p.Name
+	if len(args) > 0 {
+		name = args[0]
+	}
+	return track(p.Name, name, func() error {
+		for _, f := range res.Files {
+			if err := writePluginFile(p, f); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func callPlugin(ctx *ProjectContext, p Plugin, args []string) (*PluginResponse, error) {
+	if args == nil {
+		args = []string{}
+	}
+	req, err := json.Marshal(PluginRequest{Plugin: p.Name, Args: args, DryRun: IsDryRun(), Project: ctx})
+	if err != nil {
+		return nil, err
+	}
+
+	var stdout bytes.Buffer
+	cmd := exec.Command(p.Path)
+	cmd.Dir = ctx.Root
+	cmd.Stdin = bytes.NewReader(req)
+	cmd.Stdout = &stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("plugin %s failed: %w", p.Path, err)
+	}
+
+	res := &PluginResponse{}
+	if err := json.Unmarshal(stdout.Bytes(), res); err != nil {
+		return nil, fmt.Errorf("plugin %s returned invalid output: %w", p.Path, err)
+	}
+	return res, nil
+}
+
+func writePluginFile(p Plugin, f PluginFile) error {
+	path := filepath.FromSlash(f.Path)
+	// Plugins may only write inside the project
+	if !filepath.IsLocal(path) {
+		return fmt.Errorf("plugin %s returned a path outside the project: %q", p.Path, f.Path)
+	}
+
+	content := []byte(f.Content)
+	if strings.HasSuffix(path, ".go") {
+		formatted, err := FormatSource(path, content)
+		if err != nil {
+			return fmt.Errorf("plugin %s returned invalid Go for %s: %w", p.Path, f.Path, err)
+		}
+		content = formatted
+	}
+	return WriteFile(path, content)
+}
@@ -0,0 +1,249 @@
+package generator
+
+import (
+	"bytes"
+	"errors"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/theHamdiz/it"
+)
+
+// Registration is a call a generator injected into a function it does not own,
+// like `RegisterUserRoutes(app)` in `RegisterRoutes`. It is recorded in the
+// manifest so that `gun destroy` can take it out again.
+type Registration struct {
+	File string `json:"file"`
+	Func string `json:"func"`
+	Call string `json:"call"`
+	// Import is the package the call needs, if it lives in another package.
+	Import string `json:"import,omitempty"`
+}
+
+// register injects reg into its function, passing it the local variable named arg when there
+// is one and the function's first parameter otherwise. Calls that are already present are left
+// alone, so running a generator twice never duplicates a registration. The rest of the file,
+// including comments and edits made by hand, is kept as is.
+func register(reg Registration, arg string) error {
+	path := filepath.FromSlash(reg.File)
+	src, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		it.Warnf("%s does not exist, call %s yourself", reg.File, reg.Call)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, path, src, parser.ParseComments)
+	if err != nil {
+		return err
+	}
+	fn := findFunc(file, reg.Func)
+	if fn == nil || fn.Body == nil {
+		it.Warnf("%s has no function %s, call %s yourself", reg.File, reg.Func, reg.Call)
+		return nil
+	}
+	if len(findCalls(fn, reg.Call)) > 0 {
+		return nil
+	}
+	arg = registrationArg(fn, arg)
+	if arg == "" {
+		it.Warnf("%s in %s takes no parameter, call %s yourself", reg.Func, reg.File, reg.Call)
+		return nil
+	}
+
+	// New registrations go after the last one, or at the end of the function.
+	stmt := reg.Call + "(" + arg + ")"
+	var out []byte
+	if anchor := lastRegistration(fn); anchor != nil {
+		at := lineEnd(src, fset.Position(anchor.End()).Offset)
+		out = splice(src, at, "\t"+stmt+"\n")
+	} else {
+		at := fset.Position(fn.Body.Rbrace).Offset
+		out = splice(src, at, "\n\t"+stmt+"\n")
+	}
+
+	if reg.Import != "" && !imports(file, reg.Import) {
+		out, err = addImports(path, out, []string{reg.Import})
+		if err != nil {
+			return err
+		}
+	}
+	out, err = format.Source(out)
+	if err != nil {
+		return err
+	}
+	return write(Change{Path: path, Action: ActionModify, Before: src, After: out, Registration: &reg})
+}
+
+// unregister removes the calls injected by register, and the import they needed once nothing else uses it.
+func unregister(reg Registration) error {
+	path := filepath.FromSlash(reg.File)
+	src, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, path, src, parser.ParseComments)
+	if err != nil {
+		return err
+	}
+	fn := findFunc(file, reg.Func)
+	if fn == nil || fn.Body == nil {
+		return nil
+	}
+	calls := findCalls(fn, reg.Call)
+	if len(calls) == 0 {
+		return nil
+	}
+
+	// Cut whole lines, last call first so the earlier offsets stay valid.
+	out := src
+	for i := len(calls) - 1; i >= 0; i-- {
+		start := lineStart(out, fset.Position(calls[i].Pos()).Offset)
+		end := lineEnd(out, fset.Position(calls[i].End()).Offset)
+		// Don't leave a blank line behind at the end of the block.
+		if start > 1 && out[start-2] == '\n' && bytes.HasPrefix(bytes.TrimLeft(out[end:], " \t"), []byte("}")) {
+			start--
+		}
+		out = append(out[:start:start], out[end:]...)
+	}
+
+	if reg.Import != "" {
+		out, err = dropUnusedImport(path, out, reg.Import)
+		if err != nil {
+			return err
+		}
+	}
+	out, err = format.Source(out)
+	if err != nil {
+		return err
+	}
+	return write(Change{Path: path, Action: ActionModify, Before: src, After: out, Registration: &reg})
+}
+
+func findFunc(file *ast.File, name string) *ast.FuncDecl {
+	for _, decl := range file.Decls {
+		if fn, ok := decl.(*ast.FuncDecl); ok && fn.Recv == nil && fn.Name.Name == name {
+			return fn
+		}
+	}
+	return nil
+}
+
+// findCalls returns the statements in fn that call call, at any depth.
+func findCalls(fn *ast.FuncDecl, call string) []*ast.ExprStmt {
+	var stmts []*ast.ExprStmt
+	ast.Inspect(fn.Body, func(n ast.Node) bool {
+		if stmt, ok := n.(*ast.ExprStmt); ok && callName(stmt) == call {
+			stmts = append(stmts, stmt)
+		}
+		return true
+	})
+	return stmts
+}
+
+// callName returns the called function of an expression statement, like `routes.RegisterUserRoutes`.
+func callName(stmt ast.Stmt) string {
+	expr, ok := stmt.(*ast.ExprStmt)
+	if !ok {
+		return ""
+	}
+	call, ok := expr.X.(*ast.CallExpr)
+	if !ok {
+		return ""
+	}
+	return types.ExprString(call.Fun)
+}
+
+// lastRegistration returns the last top-level statement of fn that calls a Register function.
+func lastRegistration(fn *ast.FuncDecl) ast.Stmt {
+	var last ast.Stmt
+	for _, stmt := range fn.Body.List {
+		name := callName(stmt)
+		if i := strings.LastIndex(name, "."); i >= 0 {
+			name = name[i+1:]
+		}
+		if strings.HasPrefix(name, "Register") {
+			last = stmt
+		}
+	}
+	return last
+}
+
+// registrationArg returns local when fn declares a variable of that name, and its first parameter otherwise.
+func registrationArg(fn *ast.FuncDecl, local string) string {
+	if local != "" {
+		found := false
+		ast.Inspect(fn.Body, func(n ast.Node) bool {
+			if assign, ok := n.(*ast.AssignStmt); ok && assign.Tok == token.DEFINE {
+				for _, lhs := range assign.Lhs {
+					if ident, ok := lhs.(*ast.Ident); ok && ident.Name == local {
+						found = true
+					}
+				}
+			}
+			return !found
+		})
+		if found {
+			return local
+		}
+	}
+	params := fn.Type.Params.List
+	if len(params) == 0 || len(params[0].Names) == 0 {
+		return ""
+	}
+	return params[0].Names[0].Name
+}
+
+func imports(file *ast.File, path string) bool {
+	for _, imp := range file.Imports {
+		if p, _ := strconv.Unquote(imp.Path.Value); p == path {
+			return true
+		}
+	}
+	return false
+}
+
+// dropUnusedImport removes the import of path from src when the package is no longer referenced.
+func dropUnusedImport(filename string, src []byte, path string) ([]byte, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filename, src, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	for _, imp := range file.Imports {
+		if p, _ := strconv.Unquote(imp.Path.Value); p != path {
+			continue
+		}
+		name, _ := importName(imp, path)
+		if usedPackages(file)[name] {
+			return src, nil
+		}
+		start := lineStart(src, fset.Position(imp.Pos()).Offset)
+		end := lineEnd(src, fset.Position(imp.End()).Offset)
+		return append(src[:start:start], src[end:]...), nil
+	}
+	return src, nil
+}
+
+func splice(src []byte, at int, text string) []byte {
+	out := make([]byte, 0, len(src)+len(text))
+	out = append(out, src[:at]...)
+	out = append(out, text...)
+	return append(out, src[at:]...)
+}
@@ -0,0 +1,215 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// inTempDir runs the test from an empty directory, the way generators run from the project root.
+func inTempDir(t *testing.T) {
+	t.Helper()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(wd) })
+}
+
+func writeTestFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readTestFile(t *testing.T, path string) string {
+	t.Helper()
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(content)
+}
+
+const testRouter = `package routes
+
+import (
+	"example.com/app/internal/handlers"
+	"github.com/gofiber/fiber/v2"
+)
+
+// RegisterRoutes mounts every route of the app.
+func RegisterRoutes(app *fiber.App) {
+	// Web routes
+	app.Get("/", handlers.HomeHandler)
+
+	// Added by hand, keep it
+	app.Get("/health", func(c *fiber.Ctx) error {
+		return c.SendString("ok") // always healthy
+	})
+
+	RegisterPostRoutes(app)
+}
+
+// notFound is a handler written by hand.
+func notFound(c *fiber.Ctx) error {
+	return c.SendStatus(fiber.StatusNotFound)
+}
+`
+
+func TestRegisterRoute(t *testing.T) {
+	inTempDir(t)
+	writeTestFile(t, "internal/routes/router.go", testRouter)
+	reg := Registration{File: "internal/routes/router.go", Func: "RegisterRoutes", Call: "RegisterUserRoutes"}
+
+	for range 2 {
+		if err := register(reg, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := strings.Replace(testRouter, "\tRegisterPostRoutes(app)\n", "\tRegisterPostRoutes(app)\n\tRegisterUserRoutes(app)\n", 1)
+	if got := readTestFile(t, reg.File); got != want {
+		t.Errorf("after registering twice:\n%s\nwant\n%s", got, want)
+	}
+
+	if err := unregister(reg); err != nil {
+		t.Fatal(err)
+	}
+	if got := readTestFile(t, reg.File); got != testRouter {
+		t.Errorf("after unregistering:\n%s\nwant\n%s", got, testRouter)
+	}
+	// Unregistering again has nothing left to remove
+	if err := unregister(reg); err != nil {
+		t.Fatal(err)
+	}
+	if got := readTestFile(t, reg.File); got != testRouter {
+		t.Errorf("after unregistering twice:\n%s\nwant\n%s", got, testRouter)
+	}
+}
+
+func TestRegisterFirstRoute(t *testing.T) {
+	inTempDir(t)
+	router := `package routes
+
+import "github.com/gofiber/fiber/v2"
+
+func RegisterRoutes(app *fiber.App) {
+	// Web routes
+	app.Get("/", nil)
+}
+`
+	writeTestFile(t, "router.go", router)
+	reg := Registration{File: "router.go", Func: "RegisterRoutes", Call: "RegisterPostRoutes"}
+
+	if err := register(reg, ""); err != nil {
+		t.Fatal(err)
+	}
+	want := strings.Replace(router, "\tapp.Get(\"/\", nil)\n", "\tapp.Get(\"/\", nil)\n\n\tRegisterPostRoutes(app)\n", 1)
+	if got := readTestFile(t, reg.File); got != want {
+		t.Errorf("after registering:\n%s\nwant\n%s", got, want)
+	}
+	if err := unregister(reg); err != nil {
+		t.Fatal(err)
+	}
+	if got := readTestFile(t, reg.File); got != router {
+		t.Errorf("after unregistering:\n%s\nwant\n%s", got, router)
+	}
+}
+
+const testAPI = `package apiv1
+
+import (
+	"net/http"
+
+	"example.com/app/internal/handlers"
+)
+
+func RegisterAPIV1(mux *http.ServeMux) {
+	api := http.NewServeMux()
+	// Register API routes
+	api.HandleFunc("GET /users", handlers.GetUsers) // edited by hand
+
+	mux.Handle("/api/v1/", http.StripPrefix("/api/v1", api))
+}
+`
+
+func TestRegisterAPIRoute(t *testing.T) {
+	inTempDir(t)
+	writeTestFile(t, "cmd/http/api/v1/apiv1.go", testAPI)
+	posts := Registration{
+		File:   "cmd/http/api/v1/apiv1.go",
+		Func:   "RegisterAPIV1",
+		Call:   "routes.RegisterPostRoutes",
+		Import: "example.com/app/internal/routes",
+	}
+	tags := posts
+	tags.Call = "routes.RegisterTagRoutes"
+
+	for _, reg := range []Registration{posts, posts, tags, tags} {
+		if err := register(reg, "api"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := `package apiv1
+
+import (
+	"net/http"
+
+	"example.com/app/internal/handlers"
+	"example.com/app/internal/routes"
+)
+
+func RegisterAPIV1(mux *http.ServeMux) {
+	api := http.NewServeMux()
+	// Register API routes
+	api.HandleFunc("GET /users", handlers.GetUsers) // edited by hand
+
+	mux.Handle("/api/v1/", http.StripPrefix("/api/v1", api))
+
+	routes.RegisterPostRoutes(api)
+	routes.RegisterTagRoutes(api)
+}
+`
+	if got := readTestFile(t, posts.File); got != want {
+		t.Errorf("after registering twice:\n%s\nwant\n%s", got, want)
+	}
+
+	// The import stays as long as a call uses it
+	if err := unregister(posts); err != nil {
+		t.Fatal(err)
+	}
+	if got := readTestFile(t, posts.File); !strings.Contains(got, `"example.com/app/internal/routes"`) || strings.Contains(got, "RegisterPostRoutes") {
+		t.Errorf("after unregistering posts:\n%s", got)
+	}
+	if err := unregister(tags); err != nil {
+		t.Fatal(err)
+	}
+	if got := readTestFile(t, posts.File); got != testAPI {
+		t.Errorf("after unregistering:\n%s\nwant\n%s", got, testAPI)
+	}
+}
+
+func TestRegisterWithoutFunction(t *testing.T) {
+	inTempDir(t)
+	src := "package routes\n\n// Routes are registered elsewhere.\n"
+	writeTestFile(t, "router.go", src)
+	reg := Registration{File: "router.go", Func: "RegisterRoutes", Call: "RegisterPostRoutes"}
+
+	if err := register(reg, ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := register(Registration{File: "missing.go", Func: "RegisterRoutes", Call: "RegisterPostRoutes"}, ""); err != nil {
+		t.Fatal(err)
+	}
+	if got := readTestFile(t, reg.File); got != src {
+		t.Errorf("router changed:\n%s", got)
+	}
+}
@@ -0,0 +1,260 @@
+package generator
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"strings"
+)
+
+// Relationship kinds, as written in --fields.
+const (
+	BelongsTo  = "belongs_to"
+	HasOne     = "has_one"
+	HasMany    = "has_many"
+	ManyToMany = "many_to_many"
+)
+
+// RelationKinds lists the relationships a field can declare.
+var RelationKinds = []string{BelongsTo, HasOne, HasMany, ManyToMany}
+
+// Relation links a model to another one:
+//
+//	Author:belongs_to:User        Post.AuthorID refers to a User
+//	Profile:has_one:Profile       Profile.PostID refers to the Post
+//	Comments:has_many:Comment     Comment.PostID refers to the Post
+//	Tags:many_to_many:Tag         PostTag holds PostID and TagID
+//
+// The foreign key and the join model can be named with fk= and through=.
+type Relation struct {
+	Kind  string
+	Model string
+	// ForeignKey is the key field: on this model for belongs_to, on the related model
+	// for has_one and has_many, and this model's key on the join model for many_to_many.
+	ForeignKey string
+	// KeyType is the Go type of ForeignKey.
+	KeyType string
+	// Join is the join model of a many_to_many relationship, with References as the related
+	// model's key on it, of type ReferenceType.
+	Join          string
+	References    string
+	ReferenceType string
+}
+
+// Single reports whether the relation holds one record rather than a slice.
+func (r Relation) Single() bool {
+	return r.Kind == BelongsTo || r.Kind == HasOne
+}
+
+func parseRelation(field Field, parts []fieldToken, fail func(fieldToken, string, ...any) (Field, error)) (Field, error) {
+	kind := parts[1]
+	if len(parts) < 3 {
+		return fail(kind, "%s needs the related model, like %s:%s:User", kind.text, field.Name, kind.text)
+	}
+	model := parts[2]
+	if !isIdentifier(model.text) {
+		return fail(model, "%q is not a model name", model.text)
+	}
+
+	field.Relation = &Relation{Kind: kind.text, Model: model.text}
+	if field.Relation.Single() {
+		field.Type = "*" + model.text
+		field.Pointer = true
+	} else {
+		field.Type = "[]" + model.text
+		field.Slice = true
+	}
+
+	for _, mod := range parts[3:] {
+		key, value, hasValue := strings.Cut(mod.text, "=")
+		switch {
+		case key == "required" && !hasValue && kind.text == BelongsTo:
+			field.Required = true
+		case key == "optional" && !hasValue && kind.text == BelongsTo:
+			field.Optional = true
+		case key == "fk" && hasValue && isIdentifier(value):
+			field.Relation.ForeignKey = value
+		case key == "through" && hasValue && kind.text == ManyToMany && isIdentifier(value):
+			field.Relation.Join = value
+		default:
+			return fail(mod, "unsupported modifier %q for %s (expected fk=Name%s)", mod.text, kind.text, relationModifiers(kind.text))
+		}
+	}
+	if field.Required && field.Optional {
+		return fail(parts[0], "field %s cannot be both required and optional", field.Name)
+	}
+	return field, nil
+}
+
+func relationModifiers(kind string) string {
+	switch kind {
+	case BelongsTo:
+		return ", required or optional"
+	case ManyToMany:
+		return " or through=Model"
+	}
+	return ""
+}
+
+func isIdentifier(s string) bool {
+	return token.IsIdentifier(s) && ast.IsExported(s)
+}
+
+// resolveRelations fills in the foreign keys, key types and join models of model's relations,
+// using the schema for the key types of related models.
+func resolveRelations(schema *Schema, model string, fields []Field) []Field {
+	ownKey := keyTypeOf(fields)
+	resolved := make([]Field, len(fields))
+	for i, f := range fields {
+		if f.Relation != nil {
+			r := *f.Relation
+			switch r.Kind {
+			case BelongsTo:
+				if r.ForeignKey == "" {
+					r.ForeignKey = f.Name + "ID"
+				}
+				r.KeyType = schema.KeyType(r.Model)
+			case HasOne, HasMany:
+				if r.ForeignKey == "" {
+					r.ForeignKey = model + "ID"
+				}
+				r.KeyType = ownKey
+			case ManyToMany:
+				if r.Join == "" {
+					r.Join = model + r.Model
+				}
+				if r.ForeignKey == "" {
+					r.ForeignKey = model + "ID"
+				}
+				r.KeyType = ownKey
+				r.References = r.Model + "ID"
+				if r.References == r.ForeignKey {
+					r.References = "Related" + r.References
+				}
+				r.ReferenceType = schema.KeyType(r.Model)
+			}
+			f.Relation = &r
+		}
+		resolved[i] = f
+	}
+	return resolved
+}
+
+// withKey prepends an ID primary key to the fields of a model that other models refer to by
+// key, unless it has one.
+func withKey(fields []Field) []Field {
+	if hasField(fields, "ID") {
+		return fields
+	}
+	for _, f := range fields {
+		if f.Relation != nil && f.Relation.Kind != BelongsTo {
+			return append([]Field{{Name: "ID", Type: defaultKeyType}}, fields...)
+		}
+	}
+	return fields
+}
+
+// modelColumns returns the fields stored with a model: its scalar fields and the foreign keys
+// of its belongs_to relations.
+func modelColumns(fields []Field) []Field {
+	var columns []Field
+	for _, f := range fields {
+		if f.Relation == nil {
+			columns = append(columns, f)
+			continue
+		}
+		r := f.Relation
+		if r.Kind != BelongsTo {
+			continue
+		}
+		fk := Field{Name: r.ForeignKey, Type: r.KeyType, Required: f.Required, Index: true}
+		if f.Optional {
+			fk.makeOptional()
+		}
+		if !hasField(fields, fk.Name) {
+			columns = append(columns, fk)
+		}
+	}
+	return columns
+}
+
+// modelFields returns what the model struct holds: its columns followed by its relations.
+func modelFields(fields []Field) []Field {
+	all := modelColumns(fields)
+	for _, f := range fields {
+		if f.Relation != nil {
+			all = append(all, f)
+		}
+	}
+	return all
+}
+
+// relations returns the fields of a model that are relationships.
+func relations(fields []Field) []Field {
+	var rels []Field
+	for _, f := range fields {
+		if f.Relation != nil {
+			rels = append(rels, f)
+		}
+	}
+	return rels
+}
+
+// joinModels returns the fields of the join models of model's many_to_many relations.
+func joinModels(fields []Field) map[string][]Field {
+	joins := map[string][]Field{}
+	for _, f := range fields {
+		if r := f.Relation; r != nil && r.Kind == ManyToMany {
+			joins[r.Join] = []Field{
+				{Name: r.ForeignKey, Type: r.KeyType, Required: true, Index: true},
+				{Name: r.References, Type: r.ReferenceType, Required: true, Index: true},
+			}
+		}
+	}
+	return joins
+}
+
+// defaultKeyType is the primary key type of models that do not declare one.
+const defaultKeyType = "int"
+
+func keyTypeOf(fields []Field) string {
+	for _, f := range fields {
+		if f.Name == "ID" && f.Relation == nil {
+			return f.Type
+		}
+	}
+	return defaultKeyType
+}
+
+func hasField(fields []Field, name string) bool {
+	for _, f := range fields {
+		if f.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// parentRelations returns the relations that nest the routes of a resource under another
+// resource, like /posts/:post_id/comments: the belongs_to relations named after their model.
+func parentRelations(resource string) ([]Field, error) {
+	schema, err := LoadSchema()
+	if err != nil {
+		return nil, err
+	}
+	m := schema.Model(resource)
+	if m == nil {
+		return nil, nil
+	}
+	fields, err := m.ParsedFields()
+	if err != nil {
+		return nil, fmt.Errorf("invalid fields of %s in %s: %w", m.Name, SchemaPath, err)
+	}
+	var parents []Field
+	for _, f := range resolveRelations(schema, m.Name, fields) {
+		if f.Relation != nil && f.Relation.Kind == BelongsTo && f.Name == f.Relation.Model {
+			parents = append(parents, f)
+		}
+	}
+	return parents, nil
+}
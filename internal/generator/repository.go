@@ -0,0 +1,247 @@
+package generator
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/theHamdiz/gun/internal/inflect"
+)
+
+// repositoryData is what the repository templates render: the model, its columns and the
+// queries of the SQL implementation, written in the dialect of the project.
+type repositoryData struct {
+	*ProjectContext
+	ModelName string
+	Names     inflect.Names
+	Dialect   string
+	Table     string
+	KeyType   string
+	Options   ModelOptions
+	// Key is the column of the ID, which the database assigns when AutoKey is set.
+	Key     RepositoryColumn
+	AutoKey bool
+	// Columns are the stored fields, the key first.
+	Columns []RepositoryColumn
+	// Inserted are the columns Create writes, and Updated those Update writes.
+	Inserted []RepositoryColumn
+	Updated  []RepositoryColumn
+	// Cloned are the fields the in-memory repository copies, so that it never shares them
+	// with its callers.
+	Cloned []ClonedField
+	// HasPrepareCreate and HasPrepareUpdate are set when the model has these methods.
+	HasPrepareCreate bool
+	HasPrepareUpdate bool
+	Queries          RepositoryQueries
+}
+
+// RepositoryColumn is a field of a model stored in a column.
+type RepositoryColumn struct {
+	Field  string
+	Column string
+	// JSON is set for values a column cannot hold as such, like slices, stored as JSON.
+	JSON bool
+}
+
+// ClonedField is a field of a model referring to a value: a "slice", a "map" or a "pointer".
+type ClonedField struct {
+	Field string
+	Kind  string
+}
+
+// RepositoryQueries are the statements of the SQL repository of a model.
+type RepositoryQueries struct {
+	Select  string
+	Get     string
+	Insert  string
+	Update  string
+	Delete  string
+	Restore string
+	// Scope keeps soft deleted records out of List.
+	Scope string
+}
+
+// GenerateRepository writes the repository of a generated model: an interface with its
+// database/sql and in-memory implementations, or only the in-memory one with memoryOnly.
+func GenerateRepository(ctx *ProjectContext, name string, memoryOnly bool) error {
+	data, err := newRepositoryData(ctx, name)
+	if err != nil {
+		return err
+	}
+	// The errors and list options are shared by every repository, so they are not tracked with this one
+	if err := CreateFileFromLayout("", "repository/shared", data); err != nil {
+		return err
+	}
+	return track("repository", name, func() error {
+		if err := CreateFileFromLayout("", "repository", data); err != nil {
+			return err
+		}
+		if !memoryOnly {
+			if err := CreateFileFromLayout("", "repository/sql", data); err != nil {
+				return err
+			}
+		}
+		return CreateFileFromLayout("", "repository/memory", data)
+	})
+}
+
+func newRepositoryData(ctx *ProjectContext, name string) (repositoryData, error) {
+	schema, err := LoadSchema()
+	if err != nil {
+		return repositoryData{}, err
+	}
+	m := schema.Model(name)
+	if m == nil {
+		return repositoryData{}, fmt.Errorf("no model named %s in %s, generate it first", name, SchemaPath)
+	}
+	fields, err := m.ParsedFields()
+	if err != nil {
+		return repositoryData{}, err
+	}
+	model := inflect.Pascal(m.Name)
+	fields = resolveRelations(schema, model, withKey(fields))
+	if !hasField(fields, "ID") {
+		return repositoryData{}, fmt.Errorf("model %s has no ID field to find its records by, generate it with --id", model)
+	}
+	md, err := newModelData(ctx, model, fields)
+	if err != nil {
+		return repositoryData{}, err
+	}
+
+	data := repositoryData{
+		ProjectContext:   ctx,
+		ModelName:        model,
+		Names:            inflect.NamesFor(model),
+		Dialect:          strings.ToLower(ctx.Config.Dialect),
+		Table:            m.Table,
+		KeyType:          md.KeyType,
+		Options:          m.ModelOptions,
+		HasPrepareCreate: m.ID == "uuid" || m.ID == "ulid" || m.Timestamps,
+		HasPrepareUpdate: m.Timestamps,
+	}
+	if data.Table == "" {
+		data.Table = data.Names.Table
+	}
+	for _, f := range md.Fields {
+		if f.Relation != nil || reflect.StructTag(f.Tag).Get("db") == "-" {
+			continue
+		}
+		c := RepositoryColumn{Field: f.Name, Column: columnName(f), JSON: jsonColumn(f)}
+		if f.Name == "ID" {
+			data.Key, data.AutoKey = c, valueKind(f) == "int"
+			data.Columns = append([]RepositoryColumn{c}, data.Columns...)
+			continue
+		}
+		data.Columns = append(data.Columns, c)
+		// Records are created alive, and only Delete and Restore change the deletion time
+		if f.Managed && f.Name == "DeletedAt" {
+			continue
+		}
+		data.Inserted = append(data.Inserted, c)
+		if !(f.Managed && f.Name == "CreatedAt") {
+			data.Updated = append(data.Updated, c)
+		}
+	}
+	if !data.AutoKey {
+		data.Inserted = append([]RepositoryColumn{data.Key}, data.Inserted...)
+	}
+	for _, f := range md.Fields {
+		if kind := referenceKind(f); kind != "" {
+			data.Cloned = append(data.Cloned, ClonedField{Field: f.Name, Kind: kind})
+		}
+	}
+	data.Queries = repositoryQueries(data)
+	return data, nil
+}
+
+// referenceKind returns what a field refers to its value with: a "slice", a "map" or a
+// "pointer", or "" for a value copied with the model. Arrays are values.
+func referenceKind(f Field) string {
+	switch {
+	case strings.HasPrefix(f.Type, "[]") || f.Type == "json.RawMessage":
+		return "slice"
+	case f.Map:
+		return "map"
+	case f.Pointer:
+		return "pointer"
+	}
+	return ""
+}
+
+// jsonColumn reports whether a field is stored as JSON: the values that are neither scalars
+// nor types that convert themselves, like uuid.UUID.
+func jsonColumn(f Field) bool {
+	if len(f.Enum) > 0 {
+		return false
+	}
+	switch base := strings.TrimPrefix(f.Type, "*"); base {
+	case "string", "bool", "int", "int8", "int16", "int32", "int64", "uint", "uint8", "uint16",
+		"uint32", "uint64", "byte", "rune", "float32", "float64", "time.Time", "time.Duration",
+		"[]byte", "json.RawMessage", "uuid.UUID", "ulid.ULID":
+		return false
+	default:
+		return !strings.HasPrefix(base, "sql.Null")
+	}
+}
+
+// repositoryQueries writes the statements of the SQL repository in the dialect of the project.
+func repositoryQueries(data repositoryData) RepositoryQueries {
+	d := data.Dialect
+	bind := func(n int) string {
+		if d == "postgres" {
+			return "$" + strconv.Itoa(n)
+		}
+		return "?"
+	}
+	table := quoteIdent(d, data.Table)
+	key := quoteIdent(d, data.Key.Column)
+	names := func(columns []RepositoryColumn) []string {
+		quoted := make([]string, len(columns))
+		for i, c := range columns {
+			quoted[i] = quoteIdent(d, c.Column)
+		}
+		return quoted
+	}
+
+	var q RepositoryQueries
+	scope := ""
+	deletedAt := ""
+	for _, c := range data.Columns {
+		if c.Field == "DeletedAt" {
+			deletedAt = quoteIdent(d, c.Column)
+		}
+	}
+	if data.Options.SoftDelete {
+		q.Scope = deletedAt + " IS NULL"
+		scope = " AND " + q.Scope
+	}
+	q.Select = "SELECT " + strings.Join(names(data.Columns), ", ") + " FROM " + table
+	q.Get = q.Select + " WHERE " + key + " = " + bind(1) + scope
+
+	values := make([]string, len(data.Inserted))
+	for i := range values {
+		values[i] = bind(i + 1)
+	}
+	q.Insert = "INSERT INTO " + table + " (" + strings.Join(names(data.Inserted), ", ") + ") VALUES (" + strings.Join(values, ", ") + ")"
+	if data.AutoKey && d == "postgres" {
+		// Postgres has no LastInsertId, the key comes back from the insert
+		q.Insert += " RETURNING " + key
+	}
+
+	// A model holding nothing but its key has nothing to update
+	if sets := names(data.Updated); len(sets) > 0 {
+		for i := range sets {
+			sets[i] += " = " + bind(i+1)
+		}
+		q.Update = "UPDATE " + table + " SET " + strings.Join(sets, ", ") + " WHERE " + key + " = " + bind(len(sets)+1) + scope
+	}
+
+	if data.Options.SoftDelete {
+		q.Delete = "UPDATE " + table + " SET " + deletedAt + " = " + bind(1) + " WHERE " + key + " = " + bind(2) + scope
+		q.Restore = "UPDATE " + table + " SET " + deletedAt + " = NULL WHERE " + key + " = " + bind(1) + " AND " + deletedAt + " IS NOT NULL"
+	} else {
+		q.Delete = "DELETE FROM " + table + " WHERE " + key + " = " + bind(1)
+	}
+	return q
+}
@@ -1,40 +1,69 @@
 package generator
 
 import (
-	"fmt"
-	"os"
-	"path/filepath"
+	"path"
+
+	"github.com/theHamdiz/gun/internal/inflect"
 )
 
-func GenerateRoute(resourceName string) error {
+// GenerateRoute writes the routes of a resource and registers them in the router, or in the
+// versioned API when api is set.
+func GenerateRoute(ctx *ProjectContext, resourceName string, api bool) error {
+	parents, err := parentRelations(resourceName)
+	if err != nil {
+		return err
+	}
+	schema, err := LoadSchema()
+	if err != nil {
+		return err
+	}
 	data := struct {
+		*ProjectContext
 		ResourceName string
+		Names        inflect.Names
+		// Parents are the resources this one is nested under.
+		Parents []Field
+		// Options are the conventions of the model, like soft deletion and its restore route.
+		Options ModelOptions
 	}{
-		ResourceName: resourceName,
+		ProjectContext: ctx,
+		ResourceName:   resourceName,
+		Names:          inflect.NamesFor(resourceName),
+		Parents:        parents,
 	}
-
-	routeFilePath := filepath.Join("internal", "routes", fmt.Sprintf("%s_routes.go", ToSnakeCase(resourceName)))
-	err := os.MkdirAll(filepath.Dir(routeFilePath), 0755)
-	if err != nil {
-		return err
+	if m := schema.Model(resourceName); m != nil {
+		data.Options = m.ModelOptions
 	}
 
-	return CreateFileFromTemplate(routeFilePath, RouteTemplate, data)
-}
+	return track("route", resourceName, func() error {
+		if err := CreateFileFromLayout("", "route", data); err != nil {
+			return err
+		}
 
-// RouteTemplate is the template for generating routes
-var RouteTemplate = `package routes
-
-import (
-    "github.com/gofiber/fiber/v2"
-    "{{ .ModuleName }}/internal/handlers"
-)
+		call := "Register" + data.Names.Pascal + "Routes"
+		if !api {
+			router, err := ActivePack().Destination("router", data)
+			if err != nil {
+				return err
+			}
+			return register(Registration{File: router, Func: "RegisterRoutes", Call: call}, "")
+		}
 
-func Register{{ .ResourceName }}Routes(app *fiber.App) {
-    app.Get("/{{ ToLower .ResourceName }}s", handlers.Get{{ .ResourceName }}s)
-    app.Get("/{{ ToLower .ResourceName }}s/:id", handlers.Get{{ .ResourceName }})
-    app.Post("/{{ ToLower .ResourceName }}s", handlers.Create{{ .ResourceName }})
-    app.Put("/{{ ToLower .ResourceName }}s/:id", handlers.Update{{ .ResourceName }})
-    app.Delete("/{{ ToLower .ResourceName }}s/:id", handlers.Delete{{ .ResourceName }})
+		apiv1, err := ActivePack().Destination("apiv1", data)
+		if err != nil {
+			return err
+		}
+		routes, err := ActivePack().Destination("route", data)
+		if err != nil {
+			return err
+		}
+		// The API lives in its own package, so it calls the routes package
+		pkg := path.Dir(routes)
+		return register(Registration{
+			File:   apiv1,
+			Func:   "RegisterAPIV1",
+			Call:   path.Base(pkg) + "." + call,
+			Import: ctx.ModuleName + "/" + pkg,
+		}, "api")
+	})
 }
-`
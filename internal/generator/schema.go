@@ -0,0 +1,138 @@
+package generator
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// SchemaPath is where gun records the models generated in a project, relative to the project.
+var SchemaPath = filepath.Join(".gun", "schema.json")
+
+// Schema records the fields of every generated model, so that later generators can
+// follow relationships between models without parsing Go code.
+type Schema struct {
+	Models []ModelSchema `json:"models"`
+}
+
+// ModelSchema is a generated model with its fields in the --fields grammar.
+type ModelSchema struct {
+	Name string `json:"name"`
+	// Table is the table of a model read from an existing schema, when not named after it.
+	Table  string   `json:"table,omitempty"`
+	Fields []string `json:"fields"`
+	ModelOptions
+	// Existing and Embedded are those of the ModelDefinition the model was generated from.
+	// A model stays Existing once generated again with --fields.
+	Existing bool `json:"existing,omitempty"`
+	Embedded bool `json:"embedded,omitempty"`
+}
+
+// LoadSchema reads the project schema. A missing schema is an empty one.
+func LoadSchema() (*Schema, error) {
+	s := &Schema{}
+	data, err := os.ReadFile(SchemaPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Save writes the schema back, like Manifest.Save.
+func (s *Schema) Save() error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(SchemaPath), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(SchemaPath, append(data, '\n'), 0644)
+}
+
+// Model returns the model named name, or nil.
+func (s *Schema) Model(name string) *ModelSchema {
+	for i := range s.Models {
+		if sameName(s.Models[i].Name, name) {
+			return &s.Models[i]
+		}
+	}
+	return nil
+}
+
+// Put records a model, replacing an earlier definition. The fields added by the model
+// options are left out, see ModelSchema.ParsedFields.
+func (s *Schema) Put(name string, fields []Field) {
+	fields = unmanaged(fields)
+	m := ModelSchema{Name: name, Fields: make([]string, len(fields))}
+	for i, f := range fields {
+		m.Fields[i] = f.String()
+	}
+	if existing := s.Model(name); existing != nil {
+		*existing = m
+		return
+	}
+	s.Models = append(s.Models, m)
+}
+
+// Remove forgets the model named name.
+func (s *Schema) Remove(name string) {
+	models := s.Models[:0]
+	for _, m := range s.Models {
+		if !sameName(m.Name, name) {
+			models = append(models, m)
+		}
+	}
+	s.Models = models
+}
+
+// KeyType returns the type of the primary key of the model named name.
+func (s *Schema) KeyType(name string) string {
+	m := s.Model(name)
+	if m == nil {
+		return defaultKeyType
+	}
+	fields, err := m.ParsedFields()
+	if err != nil {
+		return defaultKeyType
+	}
+	return keyTypeOf(fields)
+}
+
+// ParsedFields parses the recorded fields of the model, with the fields its options add.
+func (m ModelSchema) ParsedFields() ([]Field, error) {
+	var fields []Field
+	for _, def := range m.Fields {
+		parsed, err := ParseFields(def)
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, parsed...)
+	}
+	return m.withFields(fields)
+}
+
+// forgetModel removes a destroyed model and the join models of its relationships from the schema.
+func forgetModel(name string) error {
+	s, err := LoadSchema()
+	if err != nil {
+		return err
+	}
+	if m := s.Model(name); m != nil {
+		if fields, err := m.ParsedFields(); err == nil {
+			for join := range joinModels(resolveRelations(s, m.Name, fields)) {
+				s.Remove(join)
+			}
+		}
+	}
+	s.Remove(name)
+	return s.Save()
+}
@@ -1,16 +1,8 @@
 package generator
 
-import (
-	"regexp"
-	"strings"
-)
+import "github.com/theHamdiz/gun/internal/inflect"
 
-// ToSnakeCase converts a string to snake_case
+// ToSnakeCase converts a string to snake_case, keeping acronyms together: HTTPServer is http_server
 func ToSnakeCase(str string) string {
-	var matchFirstCap = regexp.MustCompile("(.)([A-Z][a-z]+)")
-	var matchAllCap = regexp.MustCompile("([a-z0-9])([A-Z])")
-
-	snake := matchFirstCap.ReplaceAllString(str, "${1}_${2}")
-	snake = matchAllCap.ReplaceAllString(snake, "${1}_${2}")
-	return strings.ToLower(snake)
+	return inflect.Snake(str)
 }
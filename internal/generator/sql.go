@@ -0,0 +1,869 @@
+package generator
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/theHamdiz/gun/internal/inflect"
+)
+
+// SQLTable is a table read from a CREATE TABLE statement.
+type SQLTable struct {
+	Name    string
+	Columns []SQLColumn
+}
+
+// SQLColumn is a column of a SQLTable, with the constraints gun maps onto model fields.
+type SQLColumn struct {
+	Name string
+	// Type is the column type as written, like varchar(255) or timestamp with time zone.
+	Type       string
+	NotNull    bool
+	PrimaryKey bool
+	Unique     bool
+	Index      bool
+	// Default is the default value, unquoted. Defaults computed by the database, like now(),
+	// are not kept.
+	Default    string
+	HasDefault bool
+	// Computed is set when the database fills the column in, with a default like now() or
+	// as an auto-increment column.
+	Computed bool
+	// References is the table and column of a foreign key.
+	References *SQLReference
+}
+
+// SQLReference is the target of a foreign key.
+type SQLReference struct {
+	Table  string
+	Column string
+}
+
+// column returns the column named name, or nil.
+func (t *SQLTable) column(name string) *SQLColumn {
+	for i := range t.Columns {
+		if strings.EqualFold(t.Columns[i].Name, name) {
+			return &t.Columns[i]
+		}
+	}
+	return nil
+}
+
+// ParseSQL reads the CREATE TABLE and CREATE INDEX statements of a schema dump, written for
+// Postgres, MySQL or SQLite. Other statements are skipped, so a whole dump can be given.
+// The dialect decides how strings are quoted: only MySQL escapes quotes with backslashes.
+func ParseSQL(ddl, dialect string) ([]SQLTable, error) {
+	tokens, err := sqlTokenize(ddl, strings.EqualFold(dialect, "mysql"))
+	if err != nil {
+		return nil, err
+	}
+
+	var tables []SQLTable
+	for _, stmt := range splitStatements(tokens) {
+		p := &sqlParser{tokens: stmt}
+		switch {
+		case p.keywords("CREATE"):
+			p.keywords("OR", "REPLACE")
+			p.keywords("TEMPORARY")
+			p.keywords("TEMP")
+			p.keywords("UNLOGGED")
+			switch {
+			case p.keywords("TABLE"):
+				table, err := p.createTable()
+				if err != nil {
+					return nil, err
+				}
+				if table != nil {
+					tables = append(tables, *table)
+				}
+			case p.keywords("UNIQUE", "INDEX"):
+				p.createIndex(tables, true)
+			case p.keywords("INDEX"):
+				p.createIndex(tables, false)
+			}
+		}
+	}
+	return tables, nil
+}
+
+// sqlToken is a word, a quoted identifier, a string literal, a number or a punctuation mark.
+type sqlToken struct {
+	text string
+	kind sqlTokenKind
+	line int
+}
+
+type sqlTokenKind int
+
+const (
+	sqlWord sqlTokenKind = iota
+	sqlIdent
+	sqlString
+	sqlNumber
+	sqlPunct
+)
+
+// sqlTokenize splits src into tokens. backslashEscapes is set for MySQL, whose strings escape
+// characters with backslashes; elsewhere only Postgres E'...' strings do.
+func sqlTokenize(src string, backslashEscapes bool) ([]sqlToken, error) {
+	var tokens []sqlToken
+	line := 1
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == '\n':
+			line++
+			i++
+		case c == ' ' || c == '\t' || c == '\r':
+			i++
+		case strings.HasPrefix(src[i:], "--") || c == '#':
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+		case strings.HasPrefix(src[i:], "/*"):
+			end := strings.Index(src[i+2:], "*/")
+			if end < 0 {
+				return nil, fmt.Errorf("line %d: unterminated comment", line)
+			}
+			line += strings.Count(src[i:i+2+end], "\n")
+			i += end + 4
+		case c == '$' && dollarTag(src[i:]) != "":
+			// Postgres dollar quoting, used by function bodies that hold semicolons
+			tag := dollarTag(src[i:])
+			end := strings.Index(src[i+len(tag):], tag)
+			if end < 0 {
+				return nil, fmt.Errorf("line %d: unterminated %s string", line, tag)
+			}
+			body := src[i+len(tag) : i+len(tag)+end]
+			tokens = append(tokens, sqlToken{text: body, kind: sqlString, line: line})
+			line += strings.Count(body, "\n")
+			i += len(tag) + end + len(tag)
+		case c == '\'' || c == '"' || c == '`', (c == 'E' || c == 'e') && strings.HasPrefix(src[i+1:], "'"):
+			escapes := backslashEscapes && c == '\''
+			if c == 'E' || c == 'e' {
+				i++
+				c, escapes = '\'', true
+			}
+			text, end, err := sqlQuoted(src, i, escapes)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+			kind := sqlIdent
+			if c == '\'' {
+				kind = sqlString
+			}
+			tokens = append(tokens, sqlToken{text: text, kind: kind, line: line})
+			line += strings.Count(src[i:end], "\n")
+			i = end
+		case c >= '0' && c <= '9' || c == '.' && i+1 < len(src) && src[i+1] >= '0' && src[i+1] <= '9':
+			j := i
+			for j < len(src) && (src[j] >= '0' && src[j] <= '9' || src[j] == '.') {
+				j++
+			}
+			tokens = append(tokens, sqlToken{text: src[i:j], kind: sqlNumber, line: line})
+			i = j
+		case c == '_' || c == '$' || unicode.IsLetter(rune(c)) || c >= 0x80:
+			j := i
+			for j < len(src) && (src[j] == '_' || src[j] == '$' || src[j] >= 0x80 || unicode.IsLetter(rune(src[j])) || unicode.IsDigit(rune(src[j]))) {
+				j++
+			}
+			tokens = append(tokens, sqlToken{text: src[i:j], kind: sqlWord, line: line})
+			i = j
+		default:
+			text := string(c)
+			if strings.HasPrefix(src[i:], "::") {
+				text = "::"
+			}
+			tokens = append(tokens, sqlToken{text: text, kind: sqlPunct, line: line})
+			i += len(text)
+		}
+	}
+	return tokens, nil
+}
+
+// sqlQuoted reads the quoted string or identifier starting at src[start], and returns its text
+// and the offset past its closing quote. A doubled quote stands for the quote itself, and with
+// escapes a backslash escapes the character after it.
+func sqlQuoted(src string, start int, escapes bool) (string, int, error) {
+	closing := src[start]
+	var sb strings.Builder
+	for j := start + 1; j < len(src); j++ {
+		switch {
+		case src[j] == closing && j+1 < len(src) && src[j+1] == closing:
+			sb.WriteByte(closing)
+			j++
+		case src[j] == closing:
+			return sb.String(), j + 1, nil
+		case src[j] == '\\' && escapes && j+1 < len(src):
+			j++
+			switch src[j] {
+			case 'n':
+				sb.WriteByte('\n')
+			case 't':
+				sb.WriteByte('\t')
+			case 'r':
+				sb.WriteByte('\r')
+			case '0':
+				sb.WriteByte(0)
+			default:
+				sb.WriteByte(src[j])
+			}
+		default:
+			sb.WriteByte(src[j])
+		}
+	}
+	return "", 0, fmt.Errorf("unterminated %c", closing)
+}
+
+func splitStatements(tokens []sqlToken) [][]sqlToken {
+	var stmts [][]sqlToken
+	start := 0
+	for i, tok := range tokens {
+		if tok.kind == sqlPunct && tok.text == ";" {
+			if i > start {
+				stmts = append(stmts, tokens[start:i])
+			}
+			start = i + 1
+		}
+	}
+	if start < len(tokens) {
+		stmts = append(stmts, tokens[start:])
+	}
+	return stmts
+}
+
+type sqlParser struct {
+	tokens []sqlToken
+	pos    int
+}
+
+func (p *sqlParser) done() bool {
+	return p.pos >= len(p.tokens)
+}
+
+func (p *sqlParser) peek() sqlToken {
+	if p.done() {
+		return sqlToken{}
+	}
+	return p.tokens[p.pos]
+}
+
+func (p *sqlParser) next() sqlToken {
+	tok := p.peek()
+	p.pos++
+	return tok
+}
+
+func (p *sqlParser) isKeyword(tok sqlToken, word string) bool {
+	return tok.kind == sqlWord && strings.EqualFold(tok.text, word)
+}
+
+// keywords consumes the given words if the statement continues with all of them.
+func (p *sqlParser) keywords(words ...string) bool {
+	for i, w := range words {
+		if p.pos+i >= len(p.tokens) || !p.isKeyword(p.tokens[p.pos+i], w) {
+			return false
+		}
+	}
+	p.pos += len(words)
+	return true
+}
+
+func (p *sqlParser) punct(text string) bool {
+	if tok := p.peek(); tok.kind == sqlPunct && tok.text == text {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *sqlParser) errorf(format string, args ...any) error {
+	line := 0
+	if !p.done() {
+		line = p.peek().line
+	} else if len(p.tokens) > 0 {
+		line = p.tokens[len(p.tokens)-1].line
+	}
+	return fmt.Errorf("line %d: %s", line, fmt.Sprintf(format, args...))
+}
+
+// name reads a possibly qualified name like public.users, and returns its last part.
+func (p *sqlParser) name() (string, bool) {
+	tok := p.peek()
+	if p.done() || tok.kind != sqlWord && tok.kind != sqlIdent {
+		return "", false
+	}
+	p.pos++
+	for p.punct(".") {
+		tok = p.next()
+	}
+	return tok.text, true
+}
+
+// group reads a parenthesized group and returns the tokens inside it.
+func (p *sqlParser) group() ([]sqlToken, bool) {
+	if !p.punct("(") {
+		return nil, false
+	}
+	start, depth := p.pos, 1
+	for !p.done() {
+		tok := p.next()
+		if tok.kind != sqlPunct {
+			continue
+		}
+		switch tok.text {
+		case "(":
+			depth++
+		case ")":
+			depth--
+			if depth == 0 {
+				return p.tokens[start : p.pos-1], true
+			}
+		}
+	}
+	return nil, false
+}
+
+// splitList splits tokens at the commas outside parentheses.
+func splitList(tokens []sqlToken) [][]sqlToken {
+	var items [][]sqlToken
+	start, depth := 0, 0
+	for i, tok := range tokens {
+		if tok.kind != sqlPunct {
+			continue
+		}
+		switch tok.text {
+		case "(":
+			depth++
+		case ")":
+			depth--
+		case ",":
+			if depth == 0 {
+				items = append(items, tokens[start:i])
+				start = i + 1
+			}
+		}
+	}
+	if start < len(tokens) {
+		items = append(items, tokens[start:])
+	}
+	return items
+}
+
+// columnNames reads a parenthesized list of columns, like (author_id, post_id).
+func (p *sqlParser) columnNames() ([]string, bool) {
+	group, ok := p.group()
+	if !ok {
+		return nil, false
+	}
+	var names []string
+	for _, item := range splitList(group) {
+		// Index columns may carry a length, an order or a collation
+		if len(item) > 0 {
+			names = append(names, item[0].text)
+		}
+	}
+	return names, true
+}
+
+func (p *sqlParser) createTable() (*SQLTable, error) {
+	p.keywords("IF", "NOT", "EXISTS")
+	name, ok := p.name()
+	if !ok {
+		return nil, p.errorf("expected a table name after CREATE TABLE")
+	}
+	// CREATE TABLE ... AS SELECT and CREATE TABLE ... LIKE have no column definitions
+	if p.isKeyword(p.peek(), "AS") || p.isKeyword(p.peek(), "LIKE") {
+		return nil, nil
+	}
+	body, ok := p.group()
+	if !ok {
+		return nil, p.errorf("expected the column definitions of table %s", name)
+	}
+
+	table := &SQLTable{Name: name}
+	var constraints [][]sqlToken
+	for _, def := range splitList(body) {
+		if len(def) == 0 {
+			continue
+		}
+		if isTableConstraint(def) {
+			constraints = append(constraints, def)
+			continue
+		}
+		column, err := (&sqlParser{tokens: def}).column()
+		if err != nil {
+			return nil, fmt.Errorf("table %s: %w", name, err)
+		}
+		table.Columns = append(table.Columns, column)
+	}
+	for _, def := range constraints {
+		(&sqlParser{tokens: def}).tableConstraint(table)
+	}
+	return table, nil
+}
+
+func isTableConstraint(def []sqlToken) bool {
+	if def[0].kind != sqlWord {
+		return false
+	}
+	switch strings.ToUpper(def[0].text) {
+	case "CONSTRAINT", "PRIMARY", "FOREIGN", "CHECK", "EXCLUDE", "FULLTEXT", "SPATIAL":
+		return true
+	case "UNIQUE", "KEY", "INDEX":
+		// MySQL allows an unquoted column named key or index, followed by its type
+		return len(def) == 1 || def[1].kind != sqlWord || isKeywordAny(def[1], "KEY", "INDEX") || len(def) > 2 && def[2].kind == sqlPunct && def[2].text == "("
+	}
+	return false
+}
+
+func isKeywordAny(tok sqlToken, words ...string) bool {
+	return tok.kind == sqlWord && slices.ContainsFunc(words, func(w string) bool { return strings.EqualFold(tok.text, w) })
+}
+
+// columnConstraints are the words that end the type of a column definition.
+var columnConstraints = []string{
+	"NOT", "NULL", "PRIMARY", "UNIQUE", "DEFAULT", "REFERENCES", "CHECK", "CONSTRAINT", "COLLATE",
+	"AUTO_INCREMENT", "AUTOINCREMENT", "GENERATED", "COMMENT", "ON", "CHARACTER", "CHARSET",
+	"IDENTITY", "KEY", "AS", "STORED", "VIRTUAL",
+}
+
+func (p *sqlParser) column() (SQLColumn, error) {
+	name, ok := p.name()
+	if !ok {
+		return SQLColumn{}, p.errorf("expected a column name, got %q", p.peek().text)
+	}
+	col := SQLColumn{Name: name}
+
+	var typ []string
+	for !p.done() && !isKeywordAny(p.peek(), columnConstraints...) {
+		if group, ok := p.group(); ok {
+			var args []string
+			for _, tok := range group {
+				args = append(args, tok.text)
+			}
+			typ = append(typ, "("+strings.Join(args, "")+")")
+			continue
+		}
+		tok := p.next()
+		if tok.kind == sqlPunct && tok.text == "[" && len(typ) > 0 {
+			// Postgres arrays, like text[] or integer[3]
+			for !p.done() && !p.punct("]") {
+				p.next()
+			}
+			typ[len(typ)-1] += "[]"
+			continue
+		}
+		typ = append(typ, tok.text)
+	}
+	col.Type = strings.ReplaceAll(strings.Join(typ, " "), " (", "(")
+	if col.Type == "" {
+		// SQLite lets columns go without a type
+		col.Type = "blob"
+	}
+
+	for !p.done() {
+		switch {
+		case p.keywords("NOT", "NULL"):
+			col.NotNull = true
+		case p.keywords("NULL"):
+		case p.keywords("PRIMARY", "KEY"):
+			col.PrimaryKey, col.NotNull = true, true
+			p.keywords("ASC")
+			p.keywords("DESC")
+		case p.keywords("UNIQUE"):
+			col.Unique = true
+			p.keywords("KEY")
+		case p.keywords("DEFAULT", "NULL"):
+		case p.keywords("DEFAULT"):
+			col.Default, col.HasDefault = p.defaultValue()
+			col.Computed = !col.HasDefault
+		case isKeywordAny(p.peek(), "AUTO_INCREMENT", "AUTOINCREMENT", "IDENTITY", "GENERATED"):
+			col.Computed = true
+			p.next()
+		case p.keywords("REFERENCES"):
+			ref, ok := p.reference()
+			if !ok {
+				return col, p.errorf("expected the table column %s references", name)
+			}
+			col.References = ref
+		case p.keywords("CONSTRAINT"):
+			p.name()
+		case p.keywords("COLLATE"), p.keywords("COMMENT"), p.keywords("CHARACTER", "SET"), p.keywords("CHARSET"):
+			p.next()
+		case p.keywords("ON", "UPDATE"), p.keywords("ON", "DELETE"), p.keywords("ON", "CONFLICT"):
+			p.next()
+			p.group()
+		default:
+			// CHECK (...), GENERATED ... AS (...), AUTO_INCREMENT and the like map to nothing
+			p.next()
+			p.group()
+		}
+	}
+	return col, nil
+}
+
+// defaultValue reads the expression after DEFAULT. Only literals are kept.
+func (p *sqlParser) defaultValue() (string, bool) {
+	if group, ok := p.group(); ok {
+		// Postgres and SQLite allow DEFAULT ('draft')
+		if len(group) == 1 && (group[0].kind == sqlString || group[0].kind == sqlNumber) {
+			return group[0].text, true
+		}
+		return "", false
+	}
+	tok := p.next()
+	value, ok := "", false
+	switch {
+	case tok.kind == sqlString, tok.kind == sqlNumber:
+		value, ok = tok.text, true
+	case tok.kind == sqlPunct && (tok.text == "-" || tok.text == "+"):
+		if num := p.peek(); num.kind == sqlNumber {
+			p.next()
+			value, ok = strings.TrimPrefix(tok.text, "+")+num.text, true
+		}
+	case isKeywordAny(tok, "TRUE", "FALSE"):
+		value, ok = strings.ToLower(tok.text), true
+	case tok.kind == sqlWord:
+		// A function call like now() or nextval('users_id_seq')
+		p.group()
+	}
+	// Casts like 'draft'::text
+	for p.punct("::") {
+		p.name()
+		p.group()
+	}
+	return value, ok
+}
+
+func (p *sqlParser) reference() (*SQLReference, bool) {
+	table, ok := p.name()
+	if !ok {
+		return nil, false
+	}
+	ref := &SQLReference{Table: table, Column: "id"}
+	if columns, ok := p.columnNames(); ok && len(columns) > 0 {
+		ref.Column = columns[0]
+	}
+	return ref, true
+}
+
+func (p *sqlParser) tableConstraint(table *SQLTable) {
+	if p.keywords("CONSTRAINT") {
+		p.name()
+	}
+	switch {
+	case p.keywords("PRIMARY", "KEY"):
+		if columns, ok := p.columnNames(); ok {
+			for _, name := range columns {
+				if col := table.column(name); col != nil {
+					col.NotNull = true
+					// Composite keys are kept as plain columns
+					col.PrimaryKey = len(columns) == 1
+				}
+			}
+		}
+	case p.keywords("UNIQUE"):
+		if !p.keywords("KEY") {
+			p.keywords("INDEX")
+		}
+		if p.peek().kind != sqlPunct {
+			p.name()
+		}
+		if columns, ok := p.columnNames(); ok && len(columns) == 1 {
+			if col := table.column(columns[0]); col != nil {
+				col.Unique = true
+			}
+		}
+	case p.keywords("FOREIGN", "KEY"):
+		columns, ok := p.columnNames()
+		if !ok || len(columns) != 1 || !p.keywords("REFERENCES") {
+			return
+		}
+		if ref, ok := p.reference(); ok {
+			if col := table.column(columns[0]); col != nil {
+				col.References = ref
+			}
+		}
+	case p.keywords("KEY"), p.keywords("INDEX"):
+		if p.peek().kind != sqlPunct {
+			p.name()
+		}
+		if columns, ok := p.columnNames(); ok && len(columns) > 0 {
+			if col := table.column(columns[0]); col != nil {
+				col.Index = true
+			}
+		}
+	}
+}
+
+// createIndex reads CREATE [UNIQUE] INDEX name ON table (columns) into the table's columns.
+func (p *sqlParser) createIndex(tables []SQLTable, unique bool) {
+	p.keywords("CONCURRENTLY")
+	p.keywords("IF", "NOT", "EXISTS")
+	if !p.keywords("ON") {
+		p.name()
+		if !p.keywords("ON") {
+			return
+		}
+	}
+	p.keywords("ONLY")
+	name, ok := p.name()
+	if !ok {
+		return
+	}
+	if p.keywords("USING") {
+		p.next()
+	}
+	columns, ok := p.columnNames()
+	if !ok || len(columns) == 0 {
+		return
+	}
+	for i := range tables {
+		if !strings.EqualFold(tables[i].Name, name) {
+			continue
+		}
+		if col := tables[i].column(columns[0]); col != nil {
+			if unique && len(columns) == 1 {
+				col.Unique = true
+			} else {
+				col.Index = true
+			}
+		}
+	}
+}
+
+// sqlGoType maps a column type of any of the supported dialects to a Go type.
+func sqlGoType(typ string) string {
+	t := strings.ToLower(strings.TrimSpace(typ))
+	if elem, ok := strings.CutSuffix(t, "[]"); ok {
+		return "[]" + sqlGoType(elem)
+	}
+	if t == "tinyint(1)" || t == "bit(1)" {
+		return "bool"
+	}
+	// MySQL writes unsigned after the display width, like int(10) unsigned zerofill
+	words := strings.Fields(t)
+	unsigned := slices.Contains(words, "unsigned")
+	words = slices.DeleteFunc(words, func(w string) bool { return w == "unsigned" || w == "zerofill" })
+	base, _, _ := strings.Cut(strings.Join(words, " "), "(")
+	base = strings.TrimSpace(base)
+
+	if unsigned {
+		switch base {
+		case "tinyint":
+			return "uint8"
+		case "smallint":
+			return "uint16"
+		case "int", "integer", "mediumint":
+			return "uint32"
+		case "bigint":
+			return "uint64"
+		}
+	}
+	switch base {
+	case "bool", "boolean":
+		return "bool"
+	case "tinyint":
+		return "int8"
+	case "smallint", "int2", "smallserial", "serial2", "year":
+		return "int16"
+	case "int", "integer", "int4", "mediumint", "serial", "serial4":
+		return "int"
+	case "bigint", "int8", "bigserial", "serial8":
+		return "int64"
+	case "float4":
+		return "float32"
+	case "real", "float", "double", "double precision", "float8":
+		// REAL and FLOAT are 4 bytes in some dialects and 8 in others, float64 holds both
+		return "float64"
+	case "numeric", "decimal", "dec", "money":
+		// Exact numbers would lose precision as floats
+		return "string"
+	case "json", "jsonb":
+		return "json.RawMessage"
+	case "bytea", "blob", "tinyblob", "mediumblob", "longblob", "binary", "varbinary":
+		return "[]byte"
+	case "date", "datetime", "timestamp", "timestamptz", "timestamp with time zone", "timestamp without time zone", "smalldatetime", "datetime2", "datetimeoffset":
+		return "time.Time"
+	}
+	// SQLite type affinity covers whatever else a schema declares
+	switch {
+	case strings.Contains(base, "int"):
+		return "int64"
+	case strings.Contains(base, "char"), strings.Contains(base, "clob"), strings.Contains(base, "text"):
+		return "string"
+	case strings.Contains(base, "real"), strings.Contains(base, "floa"), strings.Contains(base, "doub"):
+		return "float64"
+	}
+	return "string"
+}
+
+// ModelDefinition is a model read from an existing schema rather than --fields.
+type ModelDefinition struct {
+	Name string
+	// Table is the table the model is stored in.
+	Table   string
+	Fields  []Field
+	Options ModelOptions
+	// Existing is set when the table was read from a database or a DDL script: it exists
+	// already, so no migration creates it.
+	Existing bool
+	// Embedded is set for a model stored within the ones using it, like the nested objects of
+	// a JSON sample, which has no table of its own.
+	Embedded bool
+}
+
+// ModelsFromSQL turns the tables of a DDL script written in dialect into models. Only the
+// tables named in tables are kept, unless it is empty. Tables come before the tables that
+// refer to them.
+func ModelsFromSQL(ddl, dialect string, tables []string) ([]ModelDefinition, error) {
+	parsed, err := ParseSQL(ddl, dialect)
+	if err != nil {
+		return nil, err
+	}
+	return ModelsFromTables(parsed, tables)
+}
+
+// ModelsFromTables maps tables to models, see ModelsFromSQL.
+func ModelsFromTables(tables []SQLTable, only []string) ([]ModelDefinition, error) {
+	for _, name := range only {
+		if !slices.ContainsFunc(tables, func(t SQLTable) bool { return strings.EqualFold(t.Name, name) }) {
+			return nil, fmt.Errorf("table %q is not defined", name)
+		}
+	}
+	if len(tables) == 0 {
+		return nil, fmt.Errorf("no CREATE TABLE statement found")
+	}
+
+	var defs []ModelDefinition
+	for _, t := range sortTables(tables) {
+		if len(only) > 0 && !slices.ContainsFunc(only, func(name string) bool { return strings.EqualFold(t.Name, name) }) {
+			continue
+		}
+		def := ModelDefinition{Name: inflect.Singular(inflect.Pascal(t.Name)), Table: t.Name, Existing: true}
+		if !isIdentifier(def.Name) {
+			return nil, fmt.Errorf("table %s does not make a valid model name", t.Name)
+		}
+		for _, col := range t.Columns {
+			f, err := columnField(t, col)
+			if err != nil {
+				return nil, fmt.Errorf("table %s: %w", t.Name, err)
+			}
+			if hasField(def.Fields, f.Name) {
+				return nil, fmt.Errorf("table %s: columns %s and %s both make field %s", t.Name, col.Name, f.Name, f.Name)
+			}
+			def.Fields = append(def.Fields, f)
+		}
+		defs = append(defs, def)
+	}
+	return defs, nil
+}
+
+// columnField maps a column to a field. A foreign key named like author_id becomes an
+// Author:belongs_to relationship using it as its key.
+func columnField(t SQLTable, col SQLColumn) (Field, error) {
+	name := inflect.Pascal(col.Name)
+	if !isIdentifier(name) {
+		return Field{}, fmt.Errorf("column %s does not make a valid field name", col.Name)
+	}
+
+	if ref := col.References; ref != nil {
+		relation, isKey := strings.CutSuffix(name, "ID")
+		if isKey && relation != "" && t.column(inflect.Snake(relation)) == nil {
+			f := Field{
+				Name:     relation,
+				Required: col.NotNull,
+				Relation: &Relation{Kind: BelongsTo, Model: inflect.Singular(inflect.Pascal(ref.Table)), ForeignKey: name},
+			}
+			f.Type, f.Pointer = "*"+f.Relation.Model, true
+			f.Optional = !col.NotNull
+			return f, nil
+		}
+	}
+
+	f := Field{Name: name}
+	if values, ok := sqlEnumValues(col.Type); ok {
+		if err := f.parseEnum(enumString(values)); err != nil {
+			return Field{}, fmt.Errorf("column %s: %w", col.Name, err)
+		}
+	} else if err := f.parseType(fieldToken{text: sqlGoType(col.Type)}); err != nil {
+		return Field{}, fmt.Errorf("column %s: %w", col.Name, err)
+	}
+	f.Unique = col.Unique && !col.PrimaryKey
+	f.Index = col.Index || col.References != nil
+	if col.HasDefault {
+		f.Default, f.HasDefault = col.Default, true
+	}
+	switch {
+	case col.PrimaryKey:
+	case col.NotNull:
+		// Columns the database fills in are not required from callers
+		f.Required = !col.HasDefault && !col.Computed
+	default:
+		f.makeOptional()
+	}
+	// Keep the column name when the field name would not map back to it
+	if inflect.Snake(name) != col.Name {
+		f.TagValues = map[string]string{"db": col.Name}
+	}
+	return f, nil
+}
+
+// sortTables orders tables so that every table comes after the tables it refers to, keeping
+// the order of the script otherwise.
+func sortTables(tables []SQLTable) []SQLTable {
+	sorted := make([]SQLTable, 0, len(tables))
+	state := map[string]int{} // 1 while visiting, 2 once sorted
+	var visit func(t SQLTable)
+	visit = func(t SQLTable) {
+		key := strings.ToLower(t.Name)
+		if state[key] != 0 {
+			return
+		}
+		state[key] = 1
+		for _, col := range t.Columns {
+			if col.References == nil {
+				continue
+			}
+			for _, ref := range tables {
+				if strings.EqualFold(ref.Name, col.References.Table) {
+					visit(ref)
+				}
+			}
+		}
+		state[key] = 2
+		sorted = append(sorted, t)
+	}
+	for _, t := range tables {
+		visit(t)
+	}
+	return sorted
+}
+
+// dollarTag returns the $tag$ that s starts with, or "".
+func dollarTag(s string) string {
+	end := strings.IndexByte(s[1:], '$')
+	if end < 0 {
+		return ""
+	}
+	tag := s[:end+2]
+	for _, r := range tag[1 : len(tag)-1] {
+		if r != '_' && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			return ""
+		}
+	}
+	return tag
+}
+
+// SQLDefault reads a column default as databases report it, like 'draft' or now(). It returns
+// the literal value, or false for a default the database computes.
+func SQLDefault(expr string) (string, bool) {
+	tokens, err := sqlTokenize(expr, false)
+	if err != nil || len(tokens) == 0 {
+		return "", false
+	}
+	return (&sqlParser{tokens: tokens}).defaultValue()
+}
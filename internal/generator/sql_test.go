@@ -0,0 +1,289 @@
+package generator
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseSQL(t *testing.T) {
+	tests := []struct {
+		name    string
+		dialect string
+		ddl     string
+		want    []SQLTable
+	}{
+		{
+			name:    "postgres",
+			dialect: "postgres",
+			ddl: `
+-- users and their posts
+CREATE TABLE public.users (
+	id bigserial PRIMARY KEY,
+	email varchar(255) NOT NULL UNIQUE,
+	name text,
+	score real,
+	balance numeric(12, 2) NOT NULL DEFAULT 0,
+	tags text[],
+	status text NOT NULL DEFAULT 'active'::text,
+	created_at timestamp with time zone NOT NULL DEFAULT now(),
+	dir text DEFAULT 'C:\',
+	path text DEFAULT E'C:\\temp\'s'
+);
+
+CREATE TABLE IF NOT EXISTS "posts" (
+	id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+	author_id bigint NOT NULL REFERENCES public.users (id) ON DELETE CASCADE,
+	title text NOT NULL CHECK (title <> ''),
+	rank integer DEFAULT -1,
+	CONSTRAINT posts_title_key UNIQUE (title)
+);
+
+CREATE INDEX CONCURRENTLY posts_author_idx ON ONLY posts USING btree (author_id);
+
+CREATE FUNCTION touch() RETURNS trigger AS $$
+BEGIN
+	NEW.updated_at = now();
+	RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+`,
+			want: []SQLTable{
+				{Name: "users", Columns: []SQLColumn{
+					{Name: "id", Type: "bigserial", NotNull: true, PrimaryKey: true},
+					{Name: "email", Type: "varchar(255)", NotNull: true, Unique: true},
+					{Name: "name", Type: "text"},
+					{Name: "score", Type: "real"},
+					{Name: "balance", Type: "numeric(12,2)", NotNull: true, Default: "0", HasDefault: true},
+					{Name: "tags", Type: "text[]"},
+					{Name: "status", Type: "text", NotNull: true, Default: "active", HasDefault: true},
+					{Name: "created_at", Type: "timestamp with time zone", NotNull: true, Computed: true},
+					{Name: "dir", Type: "text", Default: `C:\`, HasDefault: true},
+					{Name: "path", Type: "text", Default: `C:\temp's`, HasDefault: true},
+				}},
+				{Name: "posts", Columns: []SQLColumn{
+					{Name: "id", Type: "uuid", NotNull: true, PrimaryKey: true, Computed: true},
+					{Name: "author_id", Type: "bigint", NotNull: true, Index: true, References: &SQLReference{Table: "users", Column: "id"}},
+					{Name: "title", Type: "text", NotNull: true, Unique: true},
+					{Name: "rank", Type: "integer", Default: "-1", HasDefault: true},
+				}},
+			},
+		},
+		{
+			name:    "mysql",
+			dialect: "mysql",
+			ddl: "CREATE TABLE `orders` (\n" +
+				"  `id` int(10) unsigned NOT NULL AUTO_INCREMENT,\n" +
+				"  `user_id` bigint unsigned NOT NULL,\n" +
+				"  `qty` tinyint unsigned DEFAULT '1',\n" +
+				"  `active` tinyint(1) NOT NULL DEFAULT 1,\n" +
+				"  `state` enum('new','paid') NOT NULL DEFAULT 'new',\n" +
+				"  `note` varchar(50) DEFAULT 'it\\'s' COMMENT 'a \"note\"',\n" +
+				"  `total` decimal(10,2) DEFAULT NULL,\n" +
+				"  `key` varchar(20) CHARACTER SET utf8mb4 COLLATE utf8mb4_bin NOT NULL,\n" +
+				"  `updated_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,\n" +
+				"  PRIMARY KEY (`id`),\n" +
+				"  UNIQUE KEY `orders_key` (`key`),\n" +
+				"  KEY `idx_user` (`user_id`),\n" +
+				"  CONSTRAINT `fk_user` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`)\n" +
+				") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;\n",
+			want: []SQLTable{
+				{Name: "orders", Columns: []SQLColumn{
+					{Name: "id", Type: "int(10) unsigned", NotNull: true, PrimaryKey: true, Computed: true},
+					{Name: "user_id", Type: "bigint unsigned", NotNull: true, Index: true, References: &SQLReference{Table: "users", Column: "id"}},
+					{Name: "qty", Type: "tinyint unsigned", Default: "1", HasDefault: true},
+					{Name: "active", Type: "tinyint(1)", NotNull: true, Default: "1", HasDefault: true},
+					{Name: "state", Type: "enum(new,paid)", NotNull: true, Default: "new", HasDefault: true},
+					{Name: "note", Type: "varchar(50)", Default: "it's", HasDefault: true},
+					{Name: "total", Type: "decimal(10,2)"},
+					{Name: "key", Type: "varchar(20)", NotNull: true, Unique: true},
+					{Name: "updated_at", Type: "datetime", NotNull: true, Computed: true},
+				}},
+			},
+		},
+		{
+			name:    "sqlite",
+			dialect: "sqlite",
+			ddl: `
+CREATE TABLE IF NOT EXISTS comments (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	post_id TEXT NOT NULL,
+	body,
+	rating REAL DEFAULT (0.5),
+	approved BOOLEAN NOT NULL DEFAULT FALSE,
+	path TEXT DEFAULT 'C:\',
+	FOREIGN KEY (post_id) REFERENCES posts(id)
+);
+CREATE UNIQUE INDEX comments_body ON comments (body);
+
+CREATE TABLE post_tags (
+	post_id INTEGER REFERENCES posts,
+	tag_id INTEGER,
+	PRIMARY KEY (post_id, tag_id)
+) WITHOUT ROWID;
+CREATE INDEX post_tags_tag ON post_tags (tag_id);
+`,
+			want: []SQLTable{
+				{Name: "comments", Columns: []SQLColumn{
+					{Name: "id", Type: "INTEGER", NotNull: true, PrimaryKey: true, Computed: true},
+					{Name: "post_id", Type: "TEXT", NotNull: true, References: &SQLReference{Table: "posts", Column: "id"}},
+					{Name: "body", Type: "blob", Unique: true},
+					{Name: "rating", Type: "REAL", Default: "0.5", HasDefault: true},
+					{Name: "approved", Type: "BOOLEAN", NotNull: true, Default: "false", HasDefault: true},
+					{Name: "path", Type: "TEXT", Default: `C:\`, HasDefault: true},
+				}},
+				{Name: "post_tags", Columns: []SQLColumn{
+					{Name: "post_id", Type: "INTEGER", NotNull: true, References: &SQLReference{Table: "posts", Column: "id"}},
+					{Name: "tag_id", Type: "INTEGER", NotNull: true, Index: true},
+				}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseSQL(tt.ddl, tt.dialect)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("ParseSQL() returned %d tables, want %d: %+v", len(got), len(tt.want), got)
+			}
+			for i := range got {
+				if got[i].Name != tt.want[i].Name {
+					t.Errorf("table %d is %s, want %s", i, got[i].Name, tt.want[i].Name)
+				}
+				if len(got[i].Columns) != len(tt.want[i].Columns) {
+					t.Errorf("table %s has %d columns, want %d", got[i].Name, len(got[i].Columns), len(tt.want[i].Columns))
+					continue
+				}
+				for j, col := range got[i].Columns {
+					if want := tt.want[i].Columns[j]; !reflect.DeepEqual(col, want) {
+						t.Errorf("table %s column %d:\n got %+v %+v\nwant %+v %+v", got[i].Name, j, col, col.References, want, want.References)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestParseSQLErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		dialect string
+		ddl     string
+	}{
+		{"unterminated string", "postgres", "CREATE TABLE t (a text DEFAULT 'x);"},
+		// Outside MySQL, the backslash does not escape the quote, which ends the string
+		{"backslash outside mysql", "postgres", `CREATE TABLE t (a text DEFAULT 'it\'s');`},
+		{"unterminated comment", "sqlite", "CREATE TABLE t (a int); /* done"},
+		{"missing columns", "sqlite", "CREATE TABLE t;"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tables, err := ParseSQL(tt.ddl, tt.dialect); err == nil {
+				t.Errorf("ParseSQL() = %+v, want an error", tables)
+			}
+		})
+	}
+}
+
+func TestSQLGoType(t *testing.T) {
+	tests := map[string]string{
+		// Postgres
+		"smallint":                 "int16",
+		"integer":                  "int",
+		"bigint":                   "int64",
+		"bigserial":                "int64",
+		"real":                     "float64",
+		"float4":                   "float32",
+		"double precision":         "float64",
+		"numeric(12,2)":            "string",
+		"money":                    "string",
+		"boolean":                  "bool",
+		"text":                     "string",
+		"varchar(255)":             "string",
+		"character varying(20)":    "string",
+		"uuid":                     "string",
+		"jsonb":                    "json.RawMessage",
+		"bytea":                    "[]byte",
+		"timestamp with time zone": "time.Time",
+		"timestamptz":              "time.Time",
+		"date":                     "time.Time",
+		"text[]":                   "[]string",
+		"integer[]":                "[]int",
+		// MySQL
+		"tinyint(1)":                "bool",
+		"tinyint":                   "int8",
+		"tinyint unsigned":          "uint8",
+		"smallint unsigned":         "uint16",
+		"int(10) unsigned":          "uint32",
+		"int(10) unsigned zerofill": "uint32",
+		"mediumint unsigned":        "uint32",
+		"bigint(20) unsigned":       "uint64",
+		"decimal(10,2)":             "string",
+		"float":                     "float64",
+		"double":                    "float64",
+		"datetime":                  "time.Time",
+		"longblob":                  "[]byte",
+		"json":                      "json.RawMessage",
+		// SQLite affinity
+		"INTEGER":          "int",
+		"UNSIGNED BIG INT": "int64",
+		"NVARCHAR(100)":    "string",
+		"CLOB":             "string",
+		"REAL":             "float64",
+		"DOUBLE":           "float64",
+		"blob":             "[]byte",
+		"NUMERIC":          "string",
+	}
+	for typ, want := range tests {
+		if got := sqlGoType(typ); got != want {
+			t.Errorf("sqlGoType(%q) = %q, want %q", typ, got, want)
+		}
+	}
+}
+
+func TestModelsFromSQL(t *testing.T) {
+	ddl := `
+CREATE TABLE posts (
+	id bigint PRIMARY KEY,
+	author_id integer NOT NULL REFERENCES users(id),
+	title text NOT NULL,
+	body text,
+	views integer NOT NULL DEFAULT 0
+);
+CREATE TABLE users (id integer PRIMARY KEY, email text NOT NULL UNIQUE);
+`
+	defs, err := ModelsFromSQL(ddl, "postgres", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, d := range defs {
+		names = append(names, d.Name)
+	}
+	// Users come first, since posts refer to them
+	if !reflect.DeepEqual(names, []string{"User", "Post"}) {
+		t.Fatalf("models = %v, want [User Post]", names)
+	}
+
+	fields := map[string]Field{}
+	for _, f := range defs[1].Fields {
+		fields[f.Name] = f
+	}
+	if f := fields["Author"]; f.Relation == nil || f.Relation.Kind != BelongsTo || f.Relation.Model != "User" || f.Relation.ForeignKey != "AuthorID" || !f.Required {
+		t.Errorf("author_id = %+v, want a required Author:belongs_to:User", f)
+	}
+	if f := fields["Title"]; f.Type != "string" || !f.Required {
+		t.Errorf("title = %+v, want a required string", f)
+	}
+	if f := fields["Body"]; f.Type != "*string" || f.Required {
+		t.Errorf("body = %+v, want an optional *string", f)
+	}
+	if f := fields["Views"]; f.Type != "int" || f.Required || f.Default != "0" {
+		t.Errorf("views = %+v, want an int defaulting to 0", f)
+	}
+
+	if _, err := ModelsFromSQL(ddl, "postgres", []string{"comments"}); err == nil {
+		t.Error("ModelsFromSQL() selected a table that is not defined")
+	}
+}
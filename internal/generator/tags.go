@@ -0,0 +1,132 @@
+package generator
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/theHamdiz/gun/internal/config"
+)
+
+// StructTag renders the struct tag of a model field for the tag settings of the project,
+// like `json:"bornAt,omitempty" db:"born_at"`. Values set on the field itself, such as
+// json=birthday in --fields, take precedence, and a value of "-" is kept as is.
+func StructTag(tags []string, f Field) (string, error) {
+	var parts []string
+	for _, spec := range tags {
+		name, style, err := config.ParseTag(spec)
+		if err != nil {
+			return "", err
+		}
+
+		value, overridden := f.TagValues[name]
+		if !overridden {
+			value = tagValue(name, style, f)
+		}
+		if value == "" {
+			continue
+		}
+		parts = append(parts, fmt.Sprintf("%s:%q", name, value))
+	}
+	return strings.Join(parts, " "), nil
+}
+
+func tagValue(name, style string, f Field) string {
+	key := styleName(f.Name, style)
+	if f.Relation != nil {
+		return relationTagValue(name, key, f.Relation)
+	}
+	switch name {
+	case "validate":
+		return validateRules(f)
+	case "gorm":
+		rules := []string{"column:" + key}
+		if f.Required {
+			rules = append(rules, "not null")
+		}
+		if f.Unique {
+			rules = append(rules, "unique")
+		}
+		if f.Index {
+			rules = append(rules, "index")
+		}
+		if f.HasDefault {
+			rules = append(rules, "default:"+f.Default)
+		}
+		return strings.Join(rules, ";")
+	case "json", "xml", "bson":
+		if f.Optional {
+			return key + ",omitempty"
+		}
+		return key
+	default:
+		return key
+	}
+}
+
+// relationTagValue renders the tags of a relationship field, which is never a column itself.
+func relationTagValue(name, key string, r *Relation) string {
+	switch name {
+	case "json", "xml":
+		return key + ",omitempty"
+	case "gorm":
+		if r.Kind == ManyToMany {
+			return "many2many:" + ToSnakeCase(r.Join)
+		}
+		return "foreignKey:" + r.ForeignKey
+	case "validate":
+		return ""
+	default:
+		return "-"
+	}
+}
+
+// validateRules renders the field's rules for github.com/go-playground/validator.
+func validateRules(f Field) string {
+	var rules []string
+	if f.Required {
+		rules = append(rules, "required")
+	}
+	if f.Optional {
+		rules = append(rules, "omitempty")
+	}
+	if f.Email {
+		rules = append(rules, "email")
+	}
+	if f.URL {
+		rules = append(rules, "url")
+	}
+	if len(f.OneOf) > 0 {
+		rules = append(rules, "oneof="+strings.Join(f.OneOf, " "))
+	}
+	if f.Min != "" {
+		rules = append(rules, "min="+f.Min)
+	}
+	if f.Max != "" {
+		rules = append(rules, "max="+f.Max)
+	}
+	// omitempty alone validates nothing
+	if len(rules) == 1 && f.Optional {
+		return ""
+	}
+	return strings.Join(rules, ",")
+}
+
+// styleName writes a Go field name in one of config.TagStyles.
+func styleName(name, style string) string {
+	words := strings.Split(ToSnakeCase(name), "_")
+	switch style {
+	case "lower":
+		return strings.ToLower(name)
+	case "kebab":
+		return strings.Join(words, "-")
+	case "camel", "pascal":
+		for i, w := range words {
+			if i > 0 || style == "pascal" {
+				words[i] = strings.ToUpper(w[:1]) + w[1:]
+			}
+		}
+		return strings.Join(words, "")
+	default:
+		return strings.Join(words, "_")
+	}
+}
@@ -0,0 +1,465 @@
+package generator
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/types"
+	"math"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Validation is the check the Validate method of a model runs for one of its fields.
+type Validation struct {
+	// Key names the field in the errors, like its json tag.
+	Key  string
+	Code string
+}
+
+// ValidationCase is a value of a field that breaks one of its rules, for the generated tests.
+type ValidationCase struct {
+	Name  string
+	Key   string
+	Field string
+	Value string
+}
+
+// ValidationTest is what the generated test of a model needs: a valid record, and the
+// values that make it invalid.
+type ValidationTest struct {
+	// Valid are the fields set to make a valid record, as Go expressions by field name.
+	Valid []ValidValue
+	Cases []ValidationCase
+}
+
+// ValidValue is the value of one field in the valid record of a ValidationTest.
+type ValidValue struct {
+	Field string
+	Value string
+}
+
+// valueKind classifies the type of a field, without its pointer, for validation.
+func valueKind(f Field) string {
+	base := strings.TrimPrefix(f.Type, "*")
+	switch {
+	case len(f.Enum) > 0:
+		return "enum"
+	case base == "string":
+		return "string"
+	case base == "float32" || base == "float64":
+		return "float"
+	case base == "time.Time":
+		return "time"
+	case base == "any":
+		return "any"
+	case strings.HasPrefix(base, "[]"):
+		return "slice"
+	case strings.HasPrefix(base, "map["):
+		return "map"
+	case strings.HasPrefix(base, "int") || strings.HasPrefix(base, "uint") || base == "byte" || base == "rune":
+		return "int"
+	}
+	return base
+}
+
+// validationKey is the name a field goes by in validation errors: its json name when it has one.
+func validationKey(f Field) string {
+	name, _, _ := strings.Cut(reflect.StructTag(f.Tag).Get("json"), ",")
+	if name == "" || name == "-" {
+		return f.Name
+	}
+	return name
+}
+
+// bound reads min or max for a field, rounded inwards for the integers lengths and counts are.
+func bound(value string, kind string, isMin bool) (float64, string) {
+	n, _ := strconv.ParseFloat(value, 64)
+	if kind == "float" {
+		return n, value
+	}
+	if isMin {
+		n = math.Ceil(n)
+	} else {
+		n = math.Floor(n)
+	}
+	return n, strconv.FormatFloat(n, 'f', -1, 64)
+}
+
+// validations renders the checks of the column fields of a model.
+func validations(fields []Field) []Validation {
+	var out []Validation
+	for _, f := range fields {
+		if f.Relation != nil {
+			continue
+		}
+		if code := validationCode(f); code != "" {
+			out = append(out, Validation{Key: validationKey(f), Code: code})
+		}
+	}
+	return out
+}
+
+// validationCode renders the checks of one field as an if / else if chain, so a field
+// reports its first broken rule only.
+func validationCode(f Field) string {
+	kind := valueKind(f)
+	key := strconv.Quote(validationKey(f))
+	value := "m." + f.Name
+	if f.Pointer {
+		value = "*m." + f.Name
+	}
+
+	type check struct{ cond, msg string }
+	var checks []check
+	if f.Required {
+		switch {
+		case f.Pointer:
+			checks = append(checks, check{"m." + f.Name + " == nil", "is required"})
+		case kind == "string", kind == "enum":
+			checks = append(checks, check{value + ` == ""`, "is required"})
+		case kind == "int" || kind == "float":
+			checks = append(checks, check{value + " == 0", "is required"})
+		case kind == "time":
+			checks = append(checks, check{value + ".IsZero()", "is required"})
+		case kind == "slice" || kind == "map":
+			checks = append(checks, check{"len(" + value + ") == 0", "is required"})
+		case kind == "any":
+			checks = append(checks, check{value + " == nil", "is required"})
+		}
+	}
+
+	var rules []check
+	for _, b := range []struct {
+		value string
+		isMin bool
+	}{{f.Min, true}, {f.Max, false}} {
+		if b.value == "" {
+			continue
+		}
+		op, word := "<", "least"
+		if !b.isMin {
+			op, word = ">", "most"
+		}
+		_, n := bound(b.value, kind, b.isMin)
+		switch kind {
+		case "string":
+			rules = append(rules, check{fmt.Sprintf("utf8.RuneCountInString(%s) %s %s", value, op, n), fmt.Sprintf("must be at %s %s characters long", word, n)})
+		case "slice", "map":
+			rules = append(rules, check{fmt.Sprintf("len(%s) %s %s", value, op, n), fmt.Sprintf("must have at %s %s items", word, n)})
+		case "int", "float":
+			rules = append(rules, check{fmt.Sprintf("%s %s %s", value, op, n), fmt.Sprintf("must be at %s %s", word, n)})
+		}
+	}
+	if f.Email {
+		rules = append(rules, check{"!isEmail(" + value + ")", "must be a valid email address"})
+	}
+	if f.URL {
+		rules = append(rules, check{"!isURL(" + value + ")", "must be a valid URL"})
+	}
+	if len(f.OneOf) > 0 {
+		values := make([]string, len(f.OneOf))
+		for i, v := range f.OneOf {
+			values[i] = v
+			if kind == "string" {
+				values[i] = strconv.Quote(v)
+			}
+		}
+		rules = append(rules, check{
+			fmt.Sprintf("!slices.Contains([]%s{%s}, %s)", strings.TrimPrefix(f.Type, "*"), strings.Join(values, ", "), value),
+			"must be one of " + strings.Join(f.OneOf, ", "),
+		})
+	}
+	if kind == "enum" {
+		valid := value + ".Valid()"
+		if f.Pointer {
+			valid = "(" + value + ").Valid()"
+		}
+		rules = append(rules, check{"!" + valid, "must be one of " + strings.Join(f.Enum, ", ")})
+	}
+	if len(checks) == 0 && len(rules) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	chain := func(cs []check) {
+		for i, c := range cs {
+			if i > 0 {
+				sb.WriteString(" else ")
+			}
+			fmt.Fprintf(&sb, "if %s {\n\terrs.Add(%s, %q)\n}", c.cond, key, c.msg)
+		}
+	}
+	switch {
+	case f.Pointer && !f.Required && len(rules) > 0:
+		// Optional fields are only checked when set
+		fmt.Fprintf(&sb, "if m.%s != nil {\n", f.Name)
+		chain(rules)
+		sb.WriteString("\n}")
+	case f.Optional && !f.Required && len(rules) > 0:
+		fmt.Fprintf(&sb, "if len(m.%s) > 0 {\n", f.Name)
+		chain(rules)
+		sb.WriteString("\n}")
+	default:
+		chain(append(checks, rules...))
+	}
+	return sb.String()
+}
+
+// validationTest builds the test of a model's Validate method. It returns false when the
+// rules cannot be tested, because there are none or no valid value could be made for a field.
+func validationTest(fields []Field) (ValidationTest, bool) {
+	var test ValidationTest
+	for _, f := range fields {
+		if f.Relation != nil || validationCode(f) == "" {
+			continue
+		}
+		valid, ok := validValue(f)
+		if !ok {
+			return ValidationTest{}, false
+		}
+		if valid != "" {
+			test.Valid = append(test.Valid, ValidValue{Field: f.Name, Value: valid})
+		}
+		for _, c := range invalidValues(f) {
+			c.Key, c.Field = validationKey(f), f.Name
+			test.Cases = append(test.Cases, c)
+		}
+	}
+	return test, len(test.Cases) > 0
+}
+
+// pointerTo wraps the expression of a value into one of a pointer to it.
+func pointerTo(f Field, value string) string {
+	if !f.Pointer {
+		return value
+	}
+	base := strings.TrimPrefix(f.Type, "*")
+	// Untyped numbers are ints unless converted
+	if kind := valueKind(f); (kind == "int" || kind == "float") && base != "int" {
+		value = base + "(" + value + ")"
+	}
+	return fmt.Sprintf("func() %s { v := %s; return &v }()", f.Type, value)
+}
+
+// validValue returns a Go expression for a value of the field that passes its rules, or ""
+// when its zero value does.
+func validValue(f Field) (string, bool) {
+	kind := valueKind(f)
+	base := strings.TrimPrefix(f.Type, "*")
+	lo, hi := math.Inf(-1), math.Inf(1)
+	if f.Min != "" {
+		lo, _ = bound(f.Min, kind, true)
+	}
+	if f.Max != "" {
+		hi, _ = bound(f.Max, kind, false)
+	}
+	if f.Pointer && !f.Required {
+		// Optional fields are valid when left unset
+		return "", true
+	}
+
+	switch kind {
+	case "enum":
+		return pointerTo(f, enumConst(base, f.Enum[0])), true
+
+	case "string":
+		var value string
+		switch {
+		case len(f.OneOf) > 0:
+			value = f.OneOf[0]
+		case f.Email:
+			value = "user@example.com"
+		case f.URL:
+			value = "https://example.com/"
+		case f.Required || f.Min != "":
+			value = "a"
+		}
+		// Pad the value up to the minimum length, before the domain of an address
+		for len(f.OneOf) == 0 && float64(utf8.RuneCountInString(value)) < lo {
+			if f.Email {
+				value = "a" + value
+			} else {
+				value += "a"
+			}
+		}
+		if n := float64(utf8.RuneCountInString(value)); n < lo || n > hi {
+			return "", false
+		}
+		if value == "" {
+			return "", true
+		}
+		return pointerTo(f, strconv.Quote(value)), true
+
+	case "int", "float":
+		candidates := []float64{1, lo, hi, -1, lo + 1}
+		if len(f.OneOf) > 0 {
+			candidates = candidates[:0]
+			for _, v := range f.OneOf {
+				n, _ := strconv.ParseFloat(v, 64)
+				candidates = append(candidates, n)
+			}
+		}
+		for _, n := range candidates {
+			if math.IsInf(n, 0) || n < lo || n > hi || (f.Required && n == 0) {
+				continue
+			}
+			return pointerTo(f, strconv.FormatFloat(n, 'f', -1, 64)), true
+		}
+		return "", false
+
+	case "time":
+		if !f.Required {
+			return "", true
+		}
+		return pointerTo(f, "time.Now()"), true
+
+	case "slice", "map":
+		n := 0.0
+		if f.Required {
+			n = 1
+		}
+		n = math.Max(n, lo)
+		if n > hi {
+			return "", false
+		}
+		if n == 0 {
+			return "", true
+		}
+		value, ok := collection(base, int(n))
+		if !ok {
+			return "", false
+		}
+		return pointerTo(f, value), true
+	}
+
+	if f.Pointer {
+		// A required pointer to anything else only needs to be set
+		return "new(" + base + ")", true
+	}
+	if kind == "any" && f.Required {
+		return "struct{}{}", true
+	}
+	return "", true
+}
+
+// collection returns an expression for a slice or a map of n items.
+func collection(typ string, n int) (string, bool) {
+	if strings.HasPrefix(typ, "[]") {
+		return fmt.Sprintf("make(%s, %d)", typ, n), true
+	}
+	expr, err := parser.ParseExpr(typ)
+	if err != nil {
+		return "", false
+	}
+	m, ok := expr.(*ast.MapType)
+	if !ok {
+		return "", false
+	}
+	key := types.ExprString(m.Key)
+	entries := make([]string, n)
+	for i := range entries {
+		switch {
+		case key == "string":
+			entries[i] = fmt.Sprintf("%q: *new(%s)", fmt.Sprintf("k%d", i), types.ExprString(m.Value))
+		case valueKind(Field{Type: key}) == "int":
+			entries[i] = fmt.Sprintf("%d: *new(%s)", i, types.ExprString(m.Value))
+		default:
+			return "", false
+		}
+	}
+	return fmt.Sprintf("%s{%s}", typ, strings.Join(entries, ", ")), true
+}
+
+// invalidValues returns a value breaking each rule of the field.
+func invalidValues(f Field) []ValidationCase {
+	kind := valueKind(f)
+	base := strings.TrimPrefix(f.Type, "*")
+	var cases []ValidationCase
+	add := func(name, value string) {
+		cases = append(cases, ValidationCase{Name: f.Name + " " + name, Value: value})
+	}
+
+	if f.Required {
+		switch {
+		case f.Pointer, kind == "slice", kind == "map", kind == "any":
+			add("is required", "nil")
+		case kind == "string", kind == "enum":
+			add("is required", `""`)
+		case kind == "int", kind == "float":
+			add("is required", "0")
+		case kind == "time":
+			add("is required", "time.Time{}")
+		}
+	}
+	for _, b := range []struct {
+		value, name string
+		isMin       bool
+	}{{f.Min, "is too short", true}, {f.Max, "is too long", false}} {
+		if b.value == "" {
+			continue
+		}
+		n, _ := bound(b.value, kind, b.isMin)
+		if b.isMin {
+			n--
+		} else {
+			n++
+		}
+		switch kind {
+		case "string":
+			if n >= 0 {
+				add(b.name, pointerTo(f, fmt.Sprintf("strings.Repeat(%q, %d)", "a", int(n))))
+			}
+		case "slice", "map":
+			// An empty optional collection counts as unset
+			if f.Optional && !f.Required && n <= 0 {
+				continue
+			}
+			if value, ok := collection(base, int(n)); ok && n >= 0 {
+				add(strings.Replace(strings.Replace(b.name, "short", "small", 1), "long", "large", 1), pointerTo(f, value))
+			}
+		case "int", "float":
+			name := "is too small"
+			if !b.isMin {
+				name = "is too large"
+			}
+			if kind == "int" && strings.HasPrefix(base, "uint") && n < 0 {
+				continue
+			}
+			add(name, pointerTo(f, strconv.FormatFloat(n, 'f', -1, 64)))
+		}
+	}
+	if f.Email {
+		add("is not an email address", pointerTo(f, `"not-an-email"`))
+	}
+	if f.URL {
+		add("is not a URL", pointerTo(f, `"not a url"`))
+	}
+	if kind == "enum" {
+		value := "invalid"
+		for slices.Contains(f.Enum, value) {
+			value += "-value"
+		}
+		add("is not one of its values", pointerTo(f, fmt.Sprintf("%s(%q)", base, value)))
+	}
+	if len(f.OneOf) > 0 {
+		if kind == "string" {
+			value := "invalid"
+			for slices.Contains(f.OneOf, value) {
+				value += "-value"
+			}
+			add("is not one of its values", pointerTo(f, strconv.Quote(value)))
+		} else {
+			highest := math.Inf(-1)
+			for _, v := range f.OneOf {
+				n, _ := strconv.ParseFloat(v, 64)
+				highest = math.Max(highest, n)
+			}
+			add("is not one of its values", pointerTo(f, strconv.FormatFloat(math.Floor(highest)+1, 'f', -1, 64)))
+		}
+	}
+	return cases
+}
@@ -1,43 +1,54 @@
 package generator
 
 import (
-	"fmt"
-	"os"
-	"path/filepath"
+	"strings"
+
+	"github.com/theHamdiz/gun/internal/inflect"
 )
 
-func GenerateViews(resourceName string, fields []Field) error {
+func GenerateViews(ctx *ProjectContext, resourceName string, fields []Field) error {
+	return track("view", resourceName, func() error {
+		return generateViews(ctx, resourceName, fields)
+	})
+}
+
+func generateViews(ctx *ProjectContext, resourceName string, fields []Field) error {
 	views := []string{"index", "show", "edit", "new"}
-	viewsDir := filepath.Join("internal", "views", ToSnakeCase(resourceName))
-	err := os.MkdirAll(viewsDir, 0755)
+
+	// Views of a generated model default to its fields, and link the records it is related to
+	schema, err := LoadSchema()
 	if err != nil {
 		return err
 	}
+	if m := schema.Model(resourceName); m != nil && len(fields) == 0 {
+		fields, err = m.ParsedFields()
+		if err != nil {
+			return err
+		}
+	}
+	fields = resolveRelations(schema, resourceName, fields)
 
 	for _, view := range views {
 		data := struct {
+			*ProjectContext
 			ResourceName string
+			Names        inflect.Names
 			Fields       []Field
+			// FormFields are the fields forms ask for, without those the data layer maintains.
+			FormFields []Field
+			Relations  []Field
+			Style      ViewStyle
 		}{
-			ResourceName: resourceName,
-			Fields:       fields,
+			ProjectContext: ctx,
+			ResourceName:   resourceName,
+			Names:          inflect.NamesFor(resourceName),
+			Fields:         modelColumns(fields),
+			FormFields:     unmanaged(modelColumns(fields)),
+			Relations:      relations(fields),
+			Style:          viewStyleFor(ctx.Config.Style),
 		}
 
-		viewFilePath := filepath.Join(viewsDir, fmt.Sprintf("%s.html", view))
-		var templateContent string
-
-		switch view {
-		case "index":
-			templateContent = IndexTemplate
-		case "show":
-			templateContent = ShowTemplate
-		case "edit":
-			templateContent = EditTemplate
-		case "new":
-			templateContent = NewTemplate
-		}
-
-		err := CreateFileFromTemplate(viewFilePath, templateContent, data)
+		err := CreateFileFromLayout("", "views/"+view, data)
 		if err != nil {
 			return err
 		}
@@ -45,96 +56,57 @@ func GenerateViews(resourceName string, fields []Field) error {
 	return nil
 }
 
-// IndexTemplate is the template for index.html
-var IndexTemplate = `<!DOCTYPE html>
-<html lang="en">
-<head>
-    <meta charset="UTF-8">
-    <title>{{ .ResourceName }} List</title>
-    <link href="/css/tailwind.css" rel="stylesheet">
-</head>
-<body>
-    <h1>{{ .ResourceName }} List</h1>
-    <table>
-        <thead>
-            <tr>
-            {{- range .Fields }}
-                <th>{{ .Name }}</th>
-            {{- end }}
-            </tr>
-        </thead>
-        <tbody>
-            {{ "{{ range .Items }}" }}
-            <tr>
-            {{- range .Fields }}
-                <td>{{ "{{ .{{ .Name }} }}" }}</td>
-            {{- end }}
-            </tr>
-            {{ "{{ end }}" }}
-        </tbody>
-    </table>
-    <a href="/{{ ToLower .ResourceName }}s/new">Create New {{ .ResourceName }}</a>
-</body>
-</html>
-`
-
-// ShowTemplate is the template for show.html
-var ShowTemplate = `<!DOCTYPE html>
-<html lang="en">
-<head>
-    <meta charset="UTF-8">
-    <title>{{ .ResourceName }} Details</title>
-    <link href="/css/tailwind.css" rel="stylesheet">
-</head>
-<body>
-    <h1>{{ .ResourceName }} Details</h1>
-    {{- range .Fields }}
-    <p>{{ .Name }}: {{ "{{ ." }}{{ .Name }}{{ " }}" }}</p>
-    {{- end }}
-    <a href="/{{ ToLower .ResourceName }}s/{{ "{{ ." }}ID{{ " }}/edit" }}">Edit {{ .ResourceName }}</a>
-    <a href="/{{ ToLower .ResourceName }}s">Back to List</a>
-</body>
-</html>
-`
+// ViewStyle holds the stylesheet and CSS classes generated views use for a styling framework.
+type ViewStyle struct {
+	Stylesheet string
+	Table      string
+	Button     string
+	Input      string
+	Label      string
+	Link       string
+}
 
-// EditTemplate is the template for edit.html
-var EditTemplate = `<!DOCTYPE html>
-<html lang="en">
-<head>
-    <meta charset="UTF-8">
-    <title>Edit {{ .ResourceName }}</title>
-    <link href="/css/tailwind.css" rel="stylesheet">
-</head>
-<body>
-    <h1>Edit {{ .ResourceName }}</h1>
-    <form method="POST" action="/{{ ToLower .ResourceName }}s/{{ "{{ ." }}ID{{ " }}" }}">
-    {{- range .Fields }}
-        <label>{{ .Name }}</label>
-        <input type="text" name="{{ .Name }}" value="{{ "{{ ." }}{{ .Name }}{{ " }}" }}">
-    {{- end }}
-        <button type="submit">Update</button>
-    </form>
-</body>
-</html>
-`
+func viewStyleFor(style string) ViewStyle {
+	tailwindCSS := `<link href="/css/tailwind.css" rel="stylesheet">`
 
-// NewTemplate is the template for new.html
-var NewTemplate = `<!DOCTYPE html>
-<html lang="en">
-<head>
-    <meta charset="UTF-8">
-    <title>Create {{ .ResourceName }}</title>
-    <link href="/css/tailwind.css" rel="stylesheet">
-</head>
-<body>
-    <h1>Create {{ .ResourceName }}</h1>
-    <form method="POST" action="/{{ ToLower .ResourceName }}s">
-    {{- range .Fields }}
-        <label>{{ .Name }}</label>
-        <input type="text" name="{{ .Name }}">
-    {{- end }}
-        <button type="submit">Create</button>
-    </form>
-</body>
-</html>
-`
+	switch strings.ToLower(style) {
+	case "bootstrap":
+		return ViewStyle{
+			Stylesheet: `<link href="https://cdn.jsdelivr.net/npm/bootstrap@5.3.3/dist/css/bootstrap.min.css" rel="stylesheet">`,
+			Table:      "table",
+			Button:     "btn btn-primary",
+			Input:      "form-control",
+			Label:      "form-label",
+			Link:       "link-primary",
+		}
+	case "shadcn", "both":
+		return ViewStyle{
+			Stylesheet: tailwindCSS,
+			Table:      "w-full caption-bottom text-sm",
+			Button:     "inline-flex items-center justify-center rounded-md bg-primary px-4 py-2 text-sm font-medium text-primary-foreground hover:bg-primary/90",
+			Input:      "flex h-10 w-full rounded-md border border-input bg-background px-3 py-2 text-sm",
+			Label:      "text-sm font-medium leading-none",
+			Link:       "text-sm font-medium underline-offset-4 hover:underline",
+		}
+	case "daisyui":
+		return ViewStyle{
+			Stylesheet: tailwindCSS,
+			Table:      "table",
+			Button:     "btn btn-primary",
+			Input:      "input input-bordered w-full",
+			Label:      "label",
+			Link:       "link",
+		}
+	case "tailwind", "preline", "flowbite":
+		return ViewStyle{
+			Stylesheet: tailwindCSS,
+			Table:      "min-w-full divide-y divide-gray-200",
+			Button:     "rounded bg-blue-600 px-4 py-2 text-white hover:bg-blue-700",
+			Input:      "mt-1 block w-full rounded border border-gray-300 px-3 py-2",
+			Label:      "block text-sm font-medium text-gray-700",
+			Link:       "text-blue-600 hover:underline",
+		}
+	default:
+		return ViewStyle{}
+	}
+}
@@ -0,0 +1,419 @@
+// Package inflect turns names into the forms generated code needs: plurals and singulars,
+// PascalCase, camelCase, snake_case, kebab-case and human readable text, keeping acronyms
+// like ID, URL or HTTP intact.
+package inflect
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// Rules are project specific inflections, added to the built-in ones.
+type Rules struct {
+	// Acronyms are kept in upper case in PascalCase and camelCase, like "SKU".
+	Acronyms []string `json:"acronyms,omitempty"`
+	// Irregular maps singulars to their plural, like "person": "people".
+	Irregular map[string]string `json:"irregular,omitempty"`
+	// Uncountable words are their own plural, like "equipment".
+	Uncountable []string `json:"uncountable,omitempty"`
+}
+
+type rule struct {
+	pattern     *regexp.Regexp
+	replacement string
+}
+
+func rules(pairs ...string) []rule {
+	rs := make([]rule, 0, len(pairs)/2)
+	for i := 0; i < len(pairs); i += 2 {
+		rs = append(rs, rule{regexp.MustCompile(pairs[i]), pairs[i+1]})
+	}
+	return rs
+}
+
+// The first matching rule wins. Only the singulars that plural rules produce are inverted,
+// so words like drive, wave or cookie are not mistaken for the plural of something else.
+var (
+	pluralRules = rules(
+		`(quiz)$`, "${1}zes",
+		`^(ox)$`, "${1}en",
+		`([ml])ouse$`, "${1}ice",
+		`(matr|vert|ind)(?:ix|ex)$`, "${1}ices",
+		`(x|ch|ss|sh)$`, "${1}es",
+		`([^aeiouy]|qu)y$`, "${1}ies",
+		`(hive)$`, "${1}s",
+		`(kni|wi|li)fe$`, "${1}ves",
+		`(el|hal|cal|wol|lea|loa|thie|shea|scar|dwar|whar)f$`, "${1}ves",
+		`sis$`, "ses",
+		`([ti])um$`, "${1}a",
+		`(buffal|tomat|potat|her|ech|volcan|torped|vet|mosquit|domin|embarg)o$`, "${1}oes",
+		`(bu)s$`, "${1}ses",
+		`(alias|status|campus|bonus|virus|apparatus|atlas|bias|canvas|census|corpus|focus|gas|genus|iris|lens|plus)$`, "${1}es",
+		`(octop|cact|fung|alumn|radi|stimul|syllab)us$`, "${1}i",
+		`(ax|test)is$`, "${1}es",
+		`s$`, "s",
+		`$`, "s",
+	)
+	singularRules = rules(
+		`(quiz)zes$`, "${1}",
+		`(matr)ices$`, "${1}ix",
+		`(vert|ind)ices$`, "${1}ex",
+		`^(ox)en$`, "${1}",
+		`(alias|status|campus|bonus|virus|apparatus|atlas|bias|canvas|census|corpus|focus|gas|genus|iris|lens|plus)(?:es)?$`, "${1}",
+		`(octop|cact|fung|alumn|radi|stimul|syllab)(?:us|i)$`, "${1}us",
+		`^(a)x[ie]s$`, "${1}xis",
+		`(cris|test)(?:is|es)$`, "${1}is",
+		`(buffal|tomat|potat|her|ech|volcan|torped|vet|mosquit|domin|embarg)oes$`, "${1}o",
+		`(bus)(?:es)?$`, "${1}",
+		`([ml])ice$`, "${1}ouse",
+		`(cach|nich|avalanch|mou?stach|quich|clich|psych|fich)es$`, "${1}e",
+		`(x|ch|ss|sh)es$`, "${1}",
+		`(m)ovies$`, "${1}ovie",
+		`(s)eries$`, "${1}eries",
+		`^([dlpt])ies$`, "${1}ie",
+		`(aunt|bird|book|brown|calor|cook|food|goal|hipp|hood|newb|pix|prair|rook|self|smooth|sort|tech|vegg)ies$`, "${1}ie",
+		`([^aeiouy]|qu)ies$`, "${1}y",
+		`(kni|wi|li)ves$`, "${1}fe",
+		`(el|hal|cal|wol|lea|loa|thie|shea|scar|dwar|whar)ves$`, "${1}f",
+		`((a)naly|(d)iagno|(p)arenthe|(p)rogno|(s)ynop|(t)he)(?:sis|ses)$`, "${1}sis",
+		`([ti])a$`, "${1}um",
+		`(n)ews$`, "${1}ews",
+		`(ss)$`, "${1}",
+		`s$`, "",
+	)
+)
+
+var (
+	defaultAcronyms = []string{
+		"ACL", "API", "ASCII", "CPU", "CSS", "CSV", "DB", "DNS", "EOF", "GUID", "HTML", "HTTP",
+		"HTTPS", "ID", "IP", "JSON", "JWT", "OS", "QPS", "RAM", "RPC", "SLA", "SMTP", "SQL",
+		"SSH", "TCP", "TLS", "TTL", "UDP", "UI", "UID", "ULID", "URI", "URL", "UTF8", "UUID",
+		"VM", "XML", "XMPP", "XSRF", "XSS",
+	}
+	defaultIrregular = map[string]string{
+		"child": "children", "foot": "feet", "goose": "geese", "man": "men", "move": "moves",
+		"person": "people", "sex": "sexes", "tooth": "teeth", "woman": "women", "zombie": "zombies",
+	}
+	defaultUncountable = []string{
+		"data", "deer", "equipment", "feedback", "fish", "information", "jeans", "metadata",
+		"money", "news", "police", "rice", "series", "sheep", "software", "species",
+	}
+)
+
+// inflector holds the active rules. It is package state, like the rest of gun's settings.
+type inflector struct {
+	mu          sync.RWMutex
+	acronyms    map[string]string
+	plurals     map[string]string
+	singulars   map[string]string
+	uncountable map[string]bool
+}
+
+var active = newInflector(Rules{})
+
+func newInflector(custom Rules) *inflector {
+	in := &inflector{
+		acronyms:    map[string]string{},
+		plurals:     map[string]string{},
+		singulars:   map[string]string{},
+		uncountable: map[string]bool{},
+	}
+	for _, a := range append(append([]string(nil), defaultAcronyms...), custom.Acronyms...) {
+		in.acronyms[strings.ToUpper(a)] = a
+	}
+	for _, irregular := range []map[string]string{defaultIrregular, custom.Irregular} {
+		for singular, plural := range irregular {
+			in.plurals[strings.ToLower(singular)] = strings.ToLower(plural)
+			in.singulars[strings.ToLower(plural)] = strings.ToLower(singular)
+		}
+	}
+	for _, u := range append(append([]string(nil), defaultUncountable...), custom.Uncountable...) {
+		in.uncountable[strings.ToLower(u)] = true
+	}
+	return in
+}
+
+// Configure replaces the project specific rules, on top of the built-in ones.
+func Configure(custom Rules) {
+	in := newInflector(custom)
+	active.mu.Lock()
+	defer active.mu.Unlock()
+	active.acronyms, active.plurals, active.singulars, active.uncountable = in.acronyms, in.plurals, in.singulars, in.uncountable
+}
+
+// acronym returns the canonical spelling of word if it is an acronym, or its plural.
+func acronym(word string) (string, bool) {
+	active.mu.RLock()
+	defer active.mu.RUnlock()
+	if a, ok := active.acronyms[strings.ToUpper(word)]; ok {
+		return a, true
+	}
+	if strings.HasSuffix(word, "s") {
+		if a, ok := active.acronyms[strings.ToUpper(word[:len(word)-1])]; ok {
+			return a + "s", true
+		}
+	}
+	return "", false
+}
+
+// Words splits a name into its words, at separators and case changes:
+// "HTTPServer" is HTTP and Server, "userIDs" is user and IDs, "blog_post" is blog and post.
+func Words(s string) []string {
+	var words []string
+	for _, span := range wordSpans(s) {
+		words = append(words, s[span[0]:span[1]])
+	}
+	return words
+}
+
+func wordSpans(s string) [][2]int {
+	var spans [][2]int
+	runes := []rune(s)
+	// Work on byte offsets while walking runes
+	offsets := make([]int, len(runes)+1)
+	for i, pos := 0, 0; i < len(runes); i++ {
+		offsets[i] = pos
+		pos += len(string(runes[i]))
+		offsets[i+1] = pos
+	}
+
+	start := -1
+	flush := func(end int) {
+		if start >= 0 && end > start {
+			spans = append(spans, [2]int{offsets[start], offsets[end]})
+		}
+		start = -1
+	}
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			flush(i)
+			continue
+		}
+		if start < 0 {
+			start = i
+			continue
+		}
+		prev := runes[i-1]
+		switch {
+		case unicode.IsUpper(r) && (unicode.IsLower(prev) || unicode.IsDigit(prev)):
+			// userID: a new word starts at the capital
+			flush(i)
+			start = i
+		case unicode.IsUpper(r) && unicode.IsUpper(prev) && i+1 < len(runes) && unicode.IsLower(runes[i+1]):
+			// HTTPServer: the last capital of a run starts the next word, unless it is the
+			// plural of an acronym, like IDs
+			pluralAcronym := runes[i+1] == 's' && (i+2 == len(runes) || !unicode.IsLower(runes[i+2]))
+			if pluralAcronym {
+				if _, ok := acronym(string(runes[start : i+2])); ok {
+					continue
+				}
+			}
+			flush(i)
+			start = i
+		}
+	}
+	flush(len(runes))
+	return spans
+}
+
+// Pascal writes s in PascalCase, the way Go spells exported names: "user_id" is UserID.
+func Pascal(s string) string {
+	var sb strings.Builder
+	for _, w := range Words(s) {
+		sb.WriteString(capitalize(w))
+	}
+	return sb.String()
+}
+
+// Camel writes s in camelCase, the way Go spells unexported names: "UserID" is userID.
+func Camel(s string) string {
+	words := Words(s)
+	var sb strings.Builder
+	for i, w := range words {
+		if i == 0 {
+			sb.WriteString(strings.ToLower(w))
+			continue
+		}
+		sb.WriteString(capitalize(w))
+	}
+	return sb.String()
+}
+
+// Snake writes s in snake_case: "HTTPServer" is http_server.
+func Snake(s string) string {
+	return strings.ToLower(strings.Join(Words(s), "_"))
+}
+
+// Kebab writes s in kebab-case: "BlogPost" is blog-post.
+func Kebab(s string) string {
+	return strings.ToLower(strings.Join(Words(s), "-"))
+}
+
+// Humanize writes s as a sentence fragment: "BlogPostID" is "Blog post ID".
+func Humanize(s string) string {
+	words := Words(s)
+	for i, w := range words {
+		if a, ok := acronym(w); ok {
+			words[i] = a
+		} else if i == 0 {
+			words[i] = capitalize(w)
+		} else {
+			words[i] = strings.ToLower(w)
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// Title writes s as a title: "blog_post" is "Blog Post". It replaces strings.Title in templates.
+func Title(s string) string {
+	words := Words(s)
+	for i, w := range words {
+		words[i] = capitalize(w)
+	}
+	return strings.Join(words, " ")
+}
+
+// capitalize spells a word for PascalCase: acronyms in upper case, words written in capitals
+// kept as they are, anything else with a single leading capital.
+func capitalize(w string) string {
+	if a, ok := acronym(w); ok {
+		return a
+	}
+	if len(w) > 1 && strings.ToUpper(w) == w {
+		return w
+	}
+	runes := []rune(strings.ToLower(w))
+	runes[0] = unicode.ToUpper(runes[0])
+	return string(runes)
+}
+
+// Plural returns the plural of s. Only the last word changes, keeping its case:
+// "BlogCategory" is BlogCategories, "person" is people and "APIKey" is APIKeys.
+func Plural(s string) string {
+	return inflectLast(s, pluralize)
+}
+
+// Singular returns the singular of s, the inverse of Plural.
+func Singular(s string) string {
+	return inflectLast(s, singularize)
+}
+
+func inflectLast(s string, inflect func(string) string) string {
+	spans := wordSpans(s)
+	if len(spans) == 0 {
+		return s
+	}
+	last := spans[len(spans)-1]
+	return s[:last[0]] + inflect(s[last[0]:last[1]]) + s[last[1]:]
+}
+
+func pluralize(word string) string {
+	if _, ok := acronym(word); ok {
+		if strings.HasSuffix(word, "s") && word != strings.ToUpper(word) {
+			return word
+		}
+		return word + "s"
+	}
+	return inflectWord(word, func(in *inflector) map[string]string { return in.plurals }, pluralRules)
+}
+
+func singularize(word string) string {
+	if _, ok := acronym(word); ok {
+		if _, plural := acronym(strings.TrimSuffix(word, "s")); plural && strings.HasSuffix(word, "s") {
+			return word[:len(word)-1]
+		}
+		return word
+	}
+	return inflectWord(word, func(in *inflector) map[string]string { return in.singulars }, singularRules)
+}
+
+func inflectWord(word string, irregular func(*inflector) map[string]string, rs []rule) string {
+	lower := strings.ToLower(word)
+
+	active.mu.RLock()
+	uncountable := active.uncountable[lower]
+	replacement, isIrregular := irregular(active)[lower]
+	active.mu.RUnlock()
+
+	if uncountable {
+		return word
+	}
+	if !isIrregular {
+		replacement = lower
+		for _, r := range rs {
+			if r.pattern.MatchString(lower) {
+				replacement = r.pattern.ReplaceAllString(lower, r.replacement)
+				break
+			}
+		}
+	}
+	return matchCase(word, replacement)
+}
+
+// matchCase spells replacement with the capitalization of word.
+func matchCase(word, replacement string) string {
+	switch {
+	case replacement == "":
+		return replacement
+	case len(word) > 1 && strings.ToUpper(word) == word:
+		return strings.ToUpper(replacement)
+	case unicode.IsUpper([]rune(word)[0]):
+		runes := []rune(replacement)
+		runes[0] = unicode.ToUpper(runes[0])
+		return string(runes)
+	default:
+		return replacement
+	}
+}
+
+// Names holds every form of a resource name that templates use, computed once.
+type Names struct {
+	Name         string // as given on the command line
+	Pascal       string // BlogPost
+	PluralPascal string // BlogPosts
+	Camel        string // blogPost
+	PluralCamel  string // blogPosts
+	Snake        string // blog_post
+	PluralSnake  string // blog_posts
+	Kebab        string // blog-post
+	PluralKebab  string // blog-posts
+	Human        string // Blog post
+	PluralHuman  string // Blog posts
+	Title        string // Blog Post
+	PluralTitle  string // Blog Posts
+	// List names the collection in Go identifiers: BlogPosts, or StaffList when the plural is
+	// the singular, so GetStaffList and GetStaff do not collide.
+	List  string
+	Route string // blog-posts, the path segment of the resource
+	Table string // blog_posts, the table of the resource
+}
+
+// NamesFor returns the forms of name.
+func NamesFor(name string) Names {
+	singular := Pascal(name)
+	plural := Plural(singular)
+	list := plural
+	if list == singular {
+		list += "List"
+	}
+	return Names{
+		Name:         name,
+		Pascal:       singular,
+		PluralPascal: plural,
+		Camel:        Camel(singular),
+		PluralCamel:  Camel(plural),
+		Snake:        Snake(singular),
+		PluralSnake:  Snake(plural),
+		Kebab:        Kebab(singular),
+		PluralKebab:  Kebab(plural),
+		Human:        Humanize(singular),
+		PluralHuman:  Humanize(plural),
+		Title:        Title(singular),
+		PluralTitle:  Title(plural),
+		List:         list,
+		Route:        Kebab(plural),
+		Table:        Snake(plural),
+	}
+}
@@ -0,0 +1,251 @@
+package inflect
+
+import (
+	"reflect"
+	"testing"
+)
+
+// nouns are singulars and their plurals, which Plural and Singular must turn into each other.
+var nouns = []struct{ singular, plural string }{
+	{"user", "users"},
+	{"post", "posts"},
+	{"category", "categories"},
+	{"day", "days"},
+	{"key", "keys"},
+	{"address", "addresses"},
+	{"box", "boxes"},
+	{"church", "churches"},
+	{"dish", "dishes"},
+	{"quiz", "quizzes"},
+	{"status", "statuses"},
+	{"alias", "aliases"},
+	{"bus", "buses"},
+	{"campus", "campuses"},
+	{"atlas", "atlases"},
+	{"canvas", "canvases"},
+	{"lens", "lenses"},
+	{"focus", "focuses"},
+	{"octopus", "octopi"},
+	{"cactus", "cacti"},
+	{"analysis", "analyses"},
+	{"crisis", "crises"},
+	{"thesis", "theses"},
+	{"matrix", "matrices"},
+	{"index", "indices"},
+	{"vertex", "vertices"},
+	{"medium", "media"},
+	{"mouse", "mice"},
+	{"ox", "oxen"},
+	{"person", "people"},
+	{"child", "children"},
+	{"man", "men"},
+	{"woman", "women"},
+	{"tooth", "teeth"},
+	{"foot", "feet"},
+	{"movie", "movies"},
+	{"zombie", "zombies"},
+	{"series", "series"},
+	{"sheep", "sheep"},
+	{"news", "news"},
+	{"equipment", "equipment"},
+	// -o takes -oes only for some words
+	{"hero", "heroes"},
+	{"potato", "potatoes"},
+	{"echo", "echoes"},
+	{"photo", "photos"},
+	{"video", "videos"},
+	{"zoo", "zoos"},
+	// -oe is not -o
+	{"toe", "toes"},
+	{"shoe", "shoes"},
+	{"horseshoe", "horseshoes"},
+	{"canoe", "canoes"},
+	{"oboe", "oboes"},
+	{"foe", "foes"},
+	{"doe", "does"},
+	// -f and -fe take -ves only for some words
+	{"wife", "wives"},
+	{"knife", "knives"},
+	{"life", "lives"},
+	{"wolf", "wolves"},
+	{"half", "halves"},
+	{"shelf", "shelves"},
+	{"leaf", "leaves"},
+	{"thief", "thieves"},
+	{"safe", "safes"},
+	{"cafe", "cafes"},
+	{"giraffe", "giraffes"},
+	{"roof", "roofs"},
+	{"chief", "chiefs"},
+	{"golf", "golfs"},
+	{"staff", "staffs"},
+	// -ve is not -f
+	{"drive", "drives"},
+	{"wave", "waves"},
+	{"curve", "curves"},
+	{"valve", "valves"},
+	{"archive", "archives"},
+	{"objective", "objectives"},
+	{"hive", "hives"},
+	// -ie is not -y
+	{"tie", "ties"},
+	{"pie", "pies"},
+	{"cookie", "cookies"},
+	{"calorie", "calories"},
+	{"selfie", "selfies"},
+	{"city", "cities"},
+	{"party", "parties"},
+	// -se and -che are not -sis and -ch
+	{"base", "bases"},
+	{"database", "databases"},
+	{"case", "cases"},
+	{"response", "responses"},
+	{"house", "houses"},
+	{"cache", "caches"},
+	{"niche", "niches"},
+}
+
+func TestPluralAndSingular(t *testing.T) {
+	for _, n := range nouns {
+		if got := Plural(n.singular); got != n.plural {
+			t.Errorf("Plural(%q) = %q, want %q", n.singular, got, n.plural)
+		}
+		if got := Singular(n.plural); got != n.singular {
+			t.Errorf("Singular(%q) = %q, want %q", n.plural, got, n.singular)
+		}
+	}
+}
+
+func TestInflectLastWord(t *testing.T) {
+	tests := []struct {
+		in, plural, singular string
+	}{
+		{"BlogCategory", "BlogCategories", "BlogCategory"},
+		{"Toes", "Toes", "Toe"},
+		{"Safe", "Safes", "Safe"},
+		{"TOE", "TOES", "TOE"},
+		{"APIKey", "APIKeys", "APIKey"},
+		{"UserID", "UserIDs", "UserID"},
+		{"UserIDs", "UserIDs", "UserID"},
+		{"blog_post", "blog_posts", "blog_post"},
+		{"DataBase", "DataBases", "DataBase"},
+		{"SalesPerson", "SalesPeople", "SalesPerson"},
+	}
+	for _, tt := range tests {
+		if got := Plural(tt.in); got != tt.plural {
+			t.Errorf("Plural(%q) = %q, want %q", tt.in, got, tt.plural)
+		}
+		if got := Singular(tt.in); got != tt.singular {
+			t.Errorf("Singular(%q) = %q, want %q", tt.in, got, tt.singular)
+		}
+	}
+}
+
+func TestWords(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{"HTTPServer", []string{"HTTP", "Server"}},
+		{"userIDs", []string{"user", "IDs"}},
+		{"blog_post", []string{"blog", "post"}},
+		{"blog-post title", []string{"blog", "post", "title"}},
+		{"OAuth2Token", []string{"O", "Auth2", "Token"}},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		if got := Words(tt.in); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Words(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestCasing(t *testing.T) {
+	tests := []struct {
+		in                                  string
+		pascal, camel, snake, kebab, humane string
+	}{
+		{"user_id", "UserID", "userID", "user_id", "user-id", "User ID"},
+		{"HTTPServer", "HTTPServer", "httpServer", "http_server", "http-server", "HTTP server"},
+		{"blog post", "BlogPost", "blogPost", "blog_post", "blog-post", "Blog post"},
+		{"api_url", "APIURL", "apiURL", "api_url", "api-url", "API URL"},
+		{"BlogPostIDs", "BlogPostIDs", "blogPostIDs", "blog_post_ids", "blog-post-ids", "Blog post IDs"},
+	}
+	for _, tt := range tests {
+		if got := Pascal(tt.in); got != tt.pascal {
+			t.Errorf("Pascal(%q) = %q, want %q", tt.in, got, tt.pascal)
+		}
+		if got := Camel(tt.in); got != tt.camel {
+			t.Errorf("Camel(%q) = %q, want %q", tt.in, got, tt.camel)
+		}
+		if got := Snake(tt.in); got != tt.snake {
+			t.Errorf("Snake(%q) = %q, want %q", tt.in, got, tt.snake)
+		}
+		if got := Kebab(tt.in); got != tt.kebab {
+			t.Errorf("Kebab(%q) = %q, want %q", tt.in, got, tt.kebab)
+		}
+		if got := Humanize(tt.in); got != tt.humane {
+			t.Errorf("Humanize(%q) = %q, want %q", tt.in, got, tt.humane)
+		}
+	}
+}
+
+func TestNamesFor(t *testing.T) {
+	got := NamesFor("blog_category")
+	want := Names{
+		Name:         "blog_category",
+		Pascal:       "BlogCategory",
+		PluralPascal: "BlogCategories",
+		Camel:        "blogCategory",
+		PluralCamel:  "blogCategories",
+		Snake:        "blog_category",
+		PluralSnake:  "blog_categories",
+		Kebab:        "blog-category",
+		PluralKebab:  "blog-categories",
+		Human:        "Blog category",
+		PluralHuman:  "Blog categories",
+		Title:        "Blog Category",
+		PluralTitle:  "Blog Categories",
+		List:         "BlogCategories",
+		Route:        "blog-categories",
+		Table:        "blog_categories",
+	}
+	if got != want {
+		t.Errorf("NamesFor() = %+v, want %+v", got, want)
+	}
+
+	if n := NamesFor("Sheep"); n.PluralPascal != "Sheep" || n.List != "SheepList" {
+		t.Errorf("NamesFor(Sheep) = %+v, want the List of an uncountable to differ from its singular", n)
+	}
+	for _, n := range []Names{NamesFor("Toe"), NamesFor("Safe")} {
+		if Singular(n.PluralPascal) != n.Pascal {
+			t.Errorf("%s does not come back from its plural %s", n.Pascal, n.PluralPascal)
+		}
+	}
+}
+
+func TestConfigure(t *testing.T) {
+	Configure(Rules{
+		Acronyms:    []string{"SKU"},
+		Irregular:   map[string]string{"cactus": "cactuses"},
+		Uncountable: []string{"inventory"},
+	})
+	t.Cleanup(func() { Configure(Rules{}) })
+
+	if got := Pascal("product_sku"); got != "ProductSKU" {
+		t.Errorf("Pascal(product_sku) = %q, want ProductSKU", got)
+	}
+	if got := Plural("Cactus"); got != "Cactuses" {
+		t.Errorf("Plural(Cactus) = %q, want Cactuses", got)
+	}
+	if got := Singular("cactuses"); got != "cactus" {
+		t.Errorf("Singular(cactuses) = %q, want cactus", got)
+	}
+	if got := Plural("inventory"); got != "inventory" {
+		t.Errorf("Plural(inventory) = %q, want inventory", got)
+	}
+	// The built-in rules still apply
+	if got := Plural("person"); got != "people" {
+		t.Errorf("Plural(person) = %q, want people", got)
+	}
+}
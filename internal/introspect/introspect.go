@@ -0,0 +1,53 @@
+// Package introspect reads the tables of a live database, so models can be generated from
+// an existing schema. Each database is an Introspector registered under the scheme of the
+// URLs that name it, like sqlite:./app.db.
+package introspect
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/theHamdiz/gun/internal/generator"
+)
+
+// Introspector reads the tables of a database.
+type Introspector interface {
+	// Tables returns the tables of the database with their columns, keys and indexes.
+	Tables(ctx context.Context) ([]generator.SQLTable, error)
+	Close() error
+}
+
+// Opener connects to the database a URL names, without its scheme.
+type Opener func(dsn string) (Introspector, error)
+
+var openers = map[string]Opener{}
+
+// Register makes a database available under scheme.
+func Register(scheme string, open Opener) {
+	openers[scheme] = open
+}
+
+// Schemes lists the registered database schemes.
+func Schemes() []string {
+	schemes := make([]string, 0, len(openers))
+	for scheme := range openers {
+		schemes = append(schemes, scheme)
+	}
+	slices.Sort(schemes)
+	return schemes
+}
+
+// Open connects to the database named by url, like sqlite:./app.db.
+func Open(url string) (Introspector, error) {
+	scheme, dsn, ok := strings.Cut(url, ":")
+	if !ok || dsn == "" {
+		return nil, fmt.Errorf("invalid database %q (expected scheme:address, like sqlite:./app.db)", url)
+	}
+	open, ok := openers[scheme]
+	if !ok {
+		return nil, fmt.Errorf("unsupported database %q (expected one of %s)", scheme, strings.Join(Schemes(), ", "))
+	}
+	return open(strings.TrimPrefix(dsn, "//"))
+}
@@ -0,0 +1,242 @@
+package introspect
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/theHamdiz/gun/internal/generator"
+	"github.com/theHamdiz/gun/internal/migrate"
+	_ "modernc.org/sqlite"
+)
+
+func init() {
+	Register("sqlite", openSQLite)
+}
+
+// sqliteIntrospector reads an SQLite database file through its PRAGMA statements.
+type sqliteIntrospector struct {
+	db *sql.DB
+}
+
+func openSQLite(path string) (Introspector, error) {
+	// SQLite creates missing files, which would hide a mistyped path
+	if _, err := os.Stat(path); err != nil {
+		return nil, err
+	}
+	db, err := sql.Open("sqlite", "file:"+path+"?mode=ro")
+	if err != nil {
+		return nil, err
+	}
+	return &sqliteIntrospector{db: db}, nil
+}
+
+func (s *sqliteIntrospector) Close() error {
+	return s.db.Close()
+}
+
+func (s *sqliteIntrospector) Tables(ctx context.Context) ([]generator.SQLTable, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%' ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		// The bookkeeping of migrations is not a model
+		if name != migrate.Table && name != migrate.LockTable {
+			names = append(names, name)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	tables := make([]generator.SQLTable, 0, len(names))
+	for _, name := range names {
+		table, err := s.table(ctx, name)
+		if err != nil {
+			return nil, fmt.Errorf("table %s: %w", name, err)
+		}
+		tables = append(tables, table)
+	}
+	return tables, nil
+}
+
+func (s *sqliteIntrospector) table(ctx context.Context, name string) (generator.SQLTable, error) {
+	table := generator.SQLTable{Name: name}
+	quoted := `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+
+	var keys []int
+	err := s.query(ctx, "PRAGMA table_info("+quoted+")", func(rows *sql.Rows) error {
+		var (
+			cid, notNull, pk int
+			column, typ      string
+			dflt             sql.NullString
+		)
+		if err := rows.Scan(&cid, &column, &typ, &notNull, &dflt, &pk); err != nil {
+			return err
+		}
+		col := generator.SQLColumn{Name: column, Type: typ, NotNull: notNull == 1}
+		if col.Type == "" {
+			col.Type = "blob"
+		}
+		if dflt.Valid {
+			col.Default, col.HasDefault = generator.SQLDefault(dflt.String)
+			col.Computed = !col.HasDefault
+		}
+		if pk > 0 {
+			keys = append(keys, len(table.Columns))
+		}
+		table.Columns = append(table.Columns, col)
+		return nil
+	})
+	if err != nil {
+		return table, err
+	}
+	// Composite keys are kept as plain columns, like ParseSQL does
+	if len(keys) == 1 {
+		col := &table.Columns[keys[0]]
+		col.PrimaryKey, col.NotNull = true, true
+		// INTEGER PRIMARY KEY is the rowid, which SQLite assigns
+		col.Computed = strings.EqualFold(col.Type, "integer")
+	}
+	for _, i := range keys {
+		table.Columns[i].NotNull = true
+	}
+
+	if err := s.indexes(ctx, &table, quoted); err != nil {
+		return table, err
+	}
+	return table, s.foreignKeys(ctx, &table, quoted)
+}
+
+func (s *sqliteIntrospector) indexes(ctx context.Context, table *generator.SQLTable, quoted string) error {
+	type index struct {
+		name   string
+		unique bool
+	}
+	var indexes []index
+	err := s.query(ctx, "PRAGMA index_list("+quoted+")", func(rows *sql.Rows) error {
+		var (
+			seq, unique, partial int
+			name, origin         string
+		)
+		if err := rows.Scan(&seq, &name, &unique, &origin, &partial); err != nil {
+			return err
+		}
+		// The primary key is already known, and partial indexes do not make columns unique
+		if origin != "pk" && partial == 0 {
+			indexes = append(indexes, index{name: name, unique: unique == 1})
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, idx := range indexes {
+		var columns []string
+		err := s.query(ctx, `PRAGMA index_info("`+strings.ReplaceAll(idx.name, `"`, `""`)+`")`, func(rows *sql.Rows) error {
+			var (
+				seqno, cid int
+				column     sql.NullString
+			)
+			if err := rows.Scan(&seqno, &cid, &column); err != nil {
+				return err
+			}
+			columns = append(columns, column.String)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if len(columns) == 0 {
+			continue
+		}
+		col := column(table, columns[0])
+		if col == nil {
+			continue
+		}
+		if idx.unique && len(columns) == 1 {
+			col.Unique = true
+		} else {
+			col.Index = true
+		}
+	}
+	return nil
+}
+
+func (s *sqliteIntrospector) foreignKeys(ctx context.Context, table *generator.SQLTable, quoted string) error {
+	type key struct {
+		from string
+		ref  generator.SQLReference
+	}
+	keys := map[int][]key{}
+	var order []int
+	err := s.query(ctx, "PRAGMA foreign_key_list("+quoted+")", func(rows *sql.Rows) error {
+		var (
+			id, seq                                int
+			refTable, from, onUpdate, onDelete, mt string
+			to                                     sql.NullString
+		)
+		if err := rows.Scan(&id, &seq, &refTable, &from, &to, &onUpdate, &onDelete, &mt); err != nil {
+			return err
+		}
+		if _, seen := keys[id]; !seen {
+			order = append(order, id)
+		}
+		ref := generator.SQLReference{Table: refTable, Column: "id"}
+		if to.Valid && to.String != "" {
+			ref.Column = to.String
+		}
+		keys[id] = append(keys[id], key{from: from, ref: ref})
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, id := range order {
+		// Composite foreign keys do not map onto a relationship
+		if len(keys[id]) != 1 {
+			continue
+		}
+		k := keys[id][0]
+		if col := column(table, k.from); col != nil {
+			ref := k.ref
+			col.References = &ref
+		}
+	}
+	return nil
+}
+
+// query runs a statement and calls scan for each of its rows.
+func (s *sqliteIntrospector) query(ctx context.Context, query string, scan func(*sql.Rows) error) error {
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		if err := scan(rows); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func column(table *generator.SQLTable, name string) *generator.SQLColumn {
+	for i := range table.Columns {
+		if strings.EqualFold(table.Columns[i].Name, name) {
+			return &table.Columns[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package introspect
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/theHamdiz/gun/internal/config"
+	"github.com/theHamdiz/gun/internal/generator"
+	"github.com/theHamdiz/gun/internal/migrate"
+)
+
+// TestModelsFromDBMigrate generates the models of a database, like gun generate model
+// --from-db, and migrates the database after.
+func TestModelsFromDBMigrate(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "app.db")
+	db, err := sql.Open("sqlite", "file:"+path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	for _, stmt := range []string{
+		"CREATE TABLE users (id INTEGER PRIMARY KEY, email TEXT NOT NULL UNIQUE)",
+		"CREATE TABLE posts (id INTEGER PRIMARY KEY, user_id INTEGER NOT NULL REFERENCES users (id), title TEXT NOT NULL)",
+		"INSERT INTO users (email) VALUES ('a@example.com')",
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(wd) })
+	if err := os.WriteFile("go.mod", []byte("module example.com/app\n\ngo 1.23\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	project := &generator.ProjectContext{ModuleName: "example.com/app", Config: config.Default()}
+
+	in, err := Open("sqlite:" + path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tables, err := in.Tables(ctx)
+	_ = in.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defs, err := generator.ModelsFromTables(tables, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := generator.GenerateModels(project, defs); err != nil {
+		t.Fatal(err)
+	}
+	// The tables exist already, a later change to a model alters its table
+	generator.SetConflictPolicy(generator.ConflictForce)
+	t.Cleanup(func() { generator.SetConflictPolicy("") })
+	fields, err := generator.ParseFields("Email:string:unique Name:string")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := generator.GenerateModel(project, "User", fields, generator.ModelOptions{ID: "int"}); err != nil {
+		t.Fatal(err)
+	}
+
+	dir, err := generator.MigrationsDir(project)
+	if err != nil {
+		t.Fatal(err)
+	}
+	migrations, err := migrate.Load(os.DirFS(dir), ".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(migrations) != 1 || migrations[0].Name != "add_name_to_users" {
+		t.Fatalf("migrations = %v, want only add_name_to_users", migrations)
+	}
+	m, err := migrate.New(db, "sqlite", migrations)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Migrate(ctx); err != nil {
+		t.Fatal(err)
+	}
+	var name string
+	if err := db.QueryRow("SELECT name FROM users").Scan(&name); err != nil || name != "" {
+		t.Errorf("the name of the user is %q, %v, want the empty string", name, err)
+	}
+}
@@ -0,0 +1,453 @@
+// Package migrate applies the SQL migrations of a project to its database and reverts them.
+// Applied migrations are recorded in the schema_migrations table, with a checksum of their up
+// file. Each one runs in its own transaction, and a lock keeps two migrators from running
+// against a database at once.
+//
+// gun copies this file and its tests into the projects it generates, as internal/db/migrate.
+package migrate
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Table records the applied migrations.
+const Table = "schema_migrations"
+
+// LockTable holds the lock of migrators on SQLite, which has no advisory locks.
+const LockTable = Table + "_lock"
+
+// Dialects lists the databases migrations run against.
+var Dialects = []string{"postgres", "mysql", "sqlite"}
+
+// Migration is a pair of migration files, like 20240102150405_create_posts.up.sql and
+// 20240102150405_create_posts.down.sql.
+type Migration struct {
+	Version uint64
+	Name    string
+	Up      string
+	Down    string
+}
+
+func (m Migration) String() string {
+	return fmt.Sprintf("%d_%s", m.Version, m.Name)
+}
+
+// Checksum is the SHA-256 of the up file, recorded when the migration is applied. Line endings
+// are normalized, so that a checkout converting them does not change it.
+func (m Migration) Checksum() string {
+	sum := sha256.Sum256([]byte(strings.ReplaceAll(m.Up, "\r\n", "\n")))
+	return hex.EncodeToString(sum[:])
+}
+
+// Status is a migration with whether it was applied.
+type Status struct {
+	Migration
+	Applied bool
+	// AppliedAt is when the migration was applied, in RFC 3339.
+	AppliedAt string
+	// Missing is set for applied migrations whose files are gone.
+	Missing bool
+	// Changed is set for applied migrations whose up file changed since. The database does not
+	// follow the file then: revert the change and write a new migration instead.
+	Changed bool
+}
+
+var migrationFile = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// Load reads the migrations in dir, ordered by version. A missing directory has none.
+func Load(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[uint64]*Migration{}
+	for _, e := range entries {
+		match := migrationFile.FindStringSubmatch(e.Name())
+		if e.IsDir() || match == nil {
+			continue
+		}
+		version, err := strconv.ParseUint(match[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s: %w", e.Name(), err)
+		}
+		content, err := fs.ReadFile(fsys, path.Join(dir, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		m := byVersion[version]
+		if m == nil {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		} else if m.Name != match[2] {
+			return nil, fmt.Errorf("migrations %s and %d_%s share their version", m, version, match[2])
+		}
+		if match[3] == "up" {
+			m.Up = string(content)
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migration %s has no up file", m)
+		}
+		migrations = append(migrations, *m)
+	}
+	slices.SortFunc(migrations, func(a, b Migration) int {
+		return compareVersions(a.Version, b.Version)
+	})
+	return migrations, nil
+}
+
+func compareVersions(a, b uint64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// Migrator applies migrations to a database of one of the Dialects.
+type Migrator struct {
+	db         *sql.DB
+	dialect    string
+	migrations []Migration
+}
+
+// New returns a migrator of migrations, as returned by Load.
+func New(db *sql.DB, dialect string, migrations []Migration) (*Migrator, error) {
+	if !slices.Contains(Dialects, dialect) {
+		return nil, fmt.Errorf("unsupported dialect %q (expected one of %s)", dialect, strings.Join(Dialects, ", "))
+	}
+	return &Migrator{db: db, dialect: dialect, migrations: migrations}, nil
+}
+
+// Migrate applies the pending migrations in order and returns them.
+func (m *Migrator) Migrate(ctx context.Context) ([]Migration, error) {
+	var done []Migration
+	err := m.locked(ctx, func(conn *sql.Conn) error {
+		var err error
+		done, err = m.migrate(ctx, conn)
+		return err
+	})
+	return done, err
+}
+
+// Rollback reverts the last steps applied migrations, latest first, and returns them.
+func (m *Migrator) Rollback(ctx context.Context, steps int) ([]Migration, error) {
+	if steps < 1 {
+		return nil, fmt.Errorf("cannot roll back %d migrations", steps)
+	}
+	var done []Migration
+	err := m.locked(ctx, func(conn *sql.Conn) error {
+		var err error
+		done, err = m.rollback(ctx, conn, steps)
+		return err
+	})
+	return done, err
+}
+
+// Redo reverts the last applied migration and applies it again, and returns it.
+func (m *Migrator) Redo(ctx context.Context) ([]Migration, error) {
+	var done []Migration
+	err := m.locked(ctx, func(conn *sql.Conn) error {
+		var err error
+		if done, err = m.rollback(ctx, conn, 1); err != nil || len(done) == 0 {
+			return err
+		}
+		return m.run(ctx, conn, done[0], done[0].Up, true)
+	})
+	return done, err
+}
+
+// Status lists the migrations with whether they were applied, with the applied migrations
+// whose files are gone. Migrations applied before checksums were recorded are never Changed.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	if err := m.createTable(ctx, m.db); err != nil {
+		return nil, err
+	}
+	applied, err := m.applied(ctx, m.db)
+	if err != nil {
+		return nil, err
+	}
+
+	var statuses []Status
+	for _, mig := range m.migrations {
+		s := Status{Migration: mig}
+		if at, ok := applied[mig.Version]; ok {
+			s.Applied, s.AppliedAt = true, at.appliedAt
+			s.Changed = at.checksum != "" && at.checksum != mig.Checksum()
+			delete(applied, mig.Version)
+		}
+		statuses = append(statuses, s)
+	}
+	for version, at := range applied {
+		statuses = append(statuses, Status{Migration: Migration{Version: version, Name: at.name}, Applied: true, AppliedAt: at.appliedAt, Missing: true})
+	}
+	slices.SortFunc(statuses, func(a, b Status) int {
+		return compareVersions(a.Version, b.Version)
+	})
+	return statuses, nil
+}
+
+// Changed returns the applied migrations whose up file changed since they were applied.
+func (m *Migrator) Changed(ctx context.Context) ([]Migration, error) {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var changed []Migration
+	for _, s := range statuses {
+		if s.Changed {
+			changed = append(changed, s.Migration)
+		}
+	}
+	return changed, nil
+}
+
+func (m *Migrator) migrate(ctx context.Context, conn *sql.Conn) ([]Migration, error) {
+	if err := m.createTable(ctx, conn); err != nil {
+		return nil, err
+	}
+	applied, err := m.applied(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	var done []Migration
+	for _, mig := range m.migrations {
+		if _, ok := applied[mig.Version]; ok {
+			continue
+		}
+		if err := m.run(ctx, conn, mig, mig.Up, true); err != nil {
+			return done, err
+		}
+		done = append(done, mig)
+	}
+	return done, nil
+}
+
+func (m *Migrator) rollback(ctx context.Context, conn *sql.Conn, steps int) ([]Migration, error) {
+	if err := m.createTable(ctx, conn); err != nil {
+		return nil, err
+	}
+	applied, err := m.applied(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	versions := make([]uint64, 0, len(applied))
+	for version := range applied {
+		versions = append(versions, version)
+	}
+	slices.SortFunc(versions, func(a, b uint64) int { return compareVersions(b, a) })
+
+	var done []Migration
+	for _, version := range versions[:min(steps, len(versions))] {
+		i := slices.IndexFunc(m.migrations, func(mig Migration) bool { return mig.Version == version })
+		if i < 0 {
+			return done, fmt.Errorf("migration %d_%s was applied but its files are gone", version, applied[version].name)
+		}
+		mig := m.migrations[i]
+		if mig.Down == "" {
+			return done, fmt.Errorf("migration %s has no down file, it cannot be rolled back", mig)
+		}
+		if err := m.run(ctx, conn, mig, mig.Down, false); err != nil {
+			return done, err
+		}
+		done = append(done, mig)
+	}
+	return done, nil
+}
+
+// run executes the statements of a migration and records it as applied, or as reverted, in
+// a transaction on the locked connection. MySQL commits schema changes on its own, so a
+// failing migration may be left half applied there.
+func (m *Migrator) run(ctx context.Context, conn *sql.Conn, mig Migration, statements string, up bool) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if !blank(statements) {
+		if _, err := tx.ExecContext(ctx, statements); err != nil {
+			return fmt.Errorf("migration %s: %w", mig, err)
+		}
+	}
+	if up {
+		_, err = tx.ExecContext(ctx, fmt.Sprintf("INSERT INTO %s (version, name, applied_at, checksum) VALUES (%s, %s, %s, %s)", Table, m.bind(1), m.bind(2), m.bind(3), m.bind(4)),
+			mig.Version, mig.Name, time.Now().UTC().Format(time.RFC3339), mig.Checksum())
+	} else {
+		_, err = tx.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s WHERE version = %s", Table, m.bind(1)), mig.Version)
+	}
+	if err != nil {
+		return fmt.Errorf("migration %s: %w", mig, err)
+	}
+	return tx.Commit()
+}
+
+// blank reports whether a migration holds nothing but comments, which some drivers refuse
+// to execute.
+func blank(statements string) bool {
+	for _, line := range strings.Split(statements, "\n") {
+		if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "--") {
+			return false
+		}
+	}
+	return true
+}
+
+// querier is a *sql.DB, or the *sql.Conn holding the migrations lock: a migrator waiting for
+// a second connection of a pool limited to one would never get it.
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// createTable creates the table of applied migrations, and adds the checksum column to one
+// created before it existed.
+func (m *Migrator) createTable(ctx context.Context, q querier) error {
+	if _, err := q.ExecContext(ctx, "CREATE TABLE IF NOT EXISTS "+Table+" (version BIGINT NOT NULL PRIMARY KEY, name VARCHAR(255) NOT NULL, applied_at VARCHAR(64) NOT NULL, checksum VARCHAR(64) NOT NULL DEFAULT '')"); err != nil {
+		return err
+	}
+	rows, err := q.QueryContext(ctx, "SELECT checksum FROM "+Table+" WHERE 1 = 0")
+	if err == nil {
+		return rows.Close()
+	}
+	_, err = q.ExecContext(ctx, "ALTER TABLE "+Table+" ADD COLUMN checksum VARCHAR(64) NOT NULL DEFAULT ''")
+	return err
+}
+
+type appliedMigration struct {
+	name      string
+	appliedAt string
+	checksum  string
+}
+
+func (m *Migrator) applied(ctx context.Context, q querier) (map[uint64]appliedMigration, error) {
+	rows, err := q.QueryContext(ctx, "SELECT version, name, applied_at, checksum FROM "+Table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	applied := map[uint64]appliedMigration{}
+	for rows.Next() {
+		var version uint64
+		var a appliedMigration
+		if err := rows.Scan(&version, &a.name, &a.appliedAt, &a.checksum); err != nil {
+			return nil, err
+		}
+		applied[version] = a
+	}
+	return applied, rows.Err()
+}
+
+// bind is the placeholder of the nth query argument.
+func (m *Migrator) bind(n int) string {
+	if m.dialect == "postgres" {
+		return "$" + strconv.Itoa(n)
+	}
+	return "?"
+}
+
+// lockTimeout is how long a migrator waits for another one to finish.
+const lockTimeout = 15 * time.Second
+
+// lockID names the lock of migrations: the Postgres advisory lock key, and the MySQL lock name.
+const lockID = 7_264_843_627
+
+// locked runs fn holding the migrations lock of the database: an advisory lock on Postgres
+// and MySQL, and a row of schema_migrations_lock on SQLite, which has no such locks. fn runs
+// its statements on conn, the connection holding the lock.
+func (m *Migrator) locked(ctx context.Context, fn func(conn *sql.Conn) error) (err error) {
+	// Postgres and MySQL locks belong to a connection
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	deadline := time.Now().Add(lockTimeout)
+	for {
+		ok, err := m.tryLock(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if ok {
+			break
+		}
+		if time.Now().After(deadline) {
+			if m.dialect == "sqlite" {
+				return errors.New("migrations are locked by another migrator, or by one that was interrupted: delete the row of schema_migrations_lock if none is running")
+			}
+			return errors.New("migrations are locked by another migrator")
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+	defer func() {
+		// A canceled run still releases the lock
+		if unlockErr := m.unlock(context.WithoutCancel(ctx), conn); err == nil {
+			err = unlockErr
+		}
+	}()
+	return fn(conn)
+}
+
+func (m *Migrator) tryLock(ctx context.Context, conn *sql.Conn) (bool, error) {
+	switch m.dialect {
+	case "postgres":
+		var ok bool
+		err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", lockID).Scan(&ok)
+		return ok, err
+	case "mysql":
+		var ok sql.NullInt64
+		err := conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, 0)", strconv.Itoa(lockID)).Scan(&ok)
+		return ok.Int64 == 1, err
+	}
+	if _, err := conn.ExecContext(ctx, "CREATE TABLE IF NOT EXISTS "+LockTable+" (id INTEGER NOT NULL PRIMARY KEY, locked_at TEXT NOT NULL)"); err != nil {
+		return false, err
+	}
+	res, err := conn.ExecContext(ctx, "INSERT OR IGNORE INTO "+LockTable+" (id, locked_at) VALUES (1, ?)", time.Now().UTC().Format(time.RFC3339))
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
+
+func (m *Migrator) unlock(ctx context.Context, conn *sql.Conn) error {
+	var err error
+	switch m.dialect {
+	case "postgres":
+		_, err = conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", lockID)
+	case "mysql":
+		_, err = conn.ExecContext(ctx, "DO RELEASE_LOCK(?)", strconv.Itoa(lockID))
+	default:
+		_, err = conn.ExecContext(ctx, "DELETE FROM "+LockTable+" WHERE id = 1")
+	}
+	return err
+}
@@ -0,0 +1,344 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	// Migrators running at once wait for each other's writes instead of failing
+	db, err := sql.Open("sqlite", "file:"+filepath.Join(t.TempDir(), "test.db")+"?_pragma=busy_timeout(5000)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	return db
+}
+
+func tableExists(t *testing.T, db *sql.DB, table string) bool {
+	t.Helper()
+	var n int
+	if err := db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?", table).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	return n > 0
+}
+
+func load(t *testing.T, files map[string]string) []Migration {
+	t.Helper()
+	fsys := fstest.MapFS{}
+	for name, content := range files {
+		fsys[name] = &fstest.MapFile{Data: []byte(content)}
+	}
+	migrations, err := Load(fsys, ".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return migrations
+}
+
+func newMigrator(t *testing.T, db *sql.DB, migrations []Migration) *Migrator {
+	t.Helper()
+	m, err := New(db, "sqlite", migrations)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+var notes = map[string]string{
+	"1_create_notes.up.sql":   "CREATE TABLE notes (id INTEGER PRIMARY KEY);\nCREATE INDEX idx_notes_id ON notes (id);",
+	"1_create_notes.down.sql": "DROP TABLE notes;",
+	"2_add_body.up.sql":       "-- add_body (sqlite)\nALTER TABLE notes ADD COLUMN body TEXT;",
+	"2_add_body.down.sql":     "ALTER TABLE notes DROP COLUMN body;",
+	"README.md":               "not a migration",
+}
+
+func TestLoad(t *testing.T) {
+	migrations := load(t, notes)
+	if len(migrations) != 2 || migrations[0].String() != "1_create_notes" || migrations[1].String() != "2_add_body" {
+		t.Fatalf("Load() = %v, want 1_create_notes and 2_add_body", migrations)
+	}
+	if migrations[0].Down != "DROP TABLE notes;" {
+		t.Errorf("Down = %q", migrations[0].Down)
+	}
+
+	if migrations, err := Load(fstest.MapFS{}, "migrations"); err != nil || migrations != nil {
+		t.Errorf("Load() of a missing directory = %v, %v, want no migration", migrations, err)
+	}
+	for name, files := range map[string]fstest.MapFS{
+		"shared version": {"1_a.up.sql": {Data: []byte("SELECT 1;")}, "1_b.up.sql": {Data: []byte("SELECT 1;")}},
+		"no up file":     {"1_a.down.sql": {Data: []byte("SELECT 1;")}},
+	} {
+		if _, err := Load(files, "."); err == nil {
+			t.Errorf("Load() accepted migrations with %s", name)
+		}
+	}
+	if _, err := New(nil, "oracle", nil); err == nil {
+		t.Error("New() accepted an unsupported dialect")
+	}
+}
+
+func TestMigrator(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+	m := newMigrator(t, db, load(t, notes))
+
+	if done, err := m.Migrate(ctx); err != nil || len(done) != 2 {
+		t.Fatalf("Migrate() = %v, %v, want both migrations applied", done, err)
+	}
+	if done, err := m.Migrate(ctx); err != nil || len(done) != 0 {
+		t.Fatalf("Migrate() again = %v, %v, want nothing applied", done, err)
+	}
+
+	if done, err := m.Rollback(ctx, 1); err != nil || len(done) != 1 || done[0].Version != 2 {
+		t.Fatalf("Rollback(1) = %v, %v, want 2_add_body reverted", done, err)
+	}
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(statuses) != 2 || !statuses[0].Applied || statuses[0].AppliedAt == "" || statuses[1].Applied {
+		t.Fatalf("Status() = %+v, want 1 applied and 2 pending", statuses)
+	}
+
+	if done, err := m.Redo(ctx); err != nil || len(done) != 1 || done[0].Version != 1 {
+		t.Fatalf("Redo() = %v, %v, want 1_create_notes redone", done, err)
+	}
+	if !tableExists(t, db, "notes") {
+		t.Error("notes does not exist after redoing the migration creating it")
+	}
+	if done, err := m.Rollback(ctx, 5); err != nil || len(done) != 1 {
+		t.Fatalf("Rollback(5) = %v, %v, want the applied migration reverted", done, err)
+	}
+	if tableExists(t, db, "notes") {
+		t.Error("notes still exists after rolling back every migration")
+	}
+	if _, err := m.Rollback(ctx, 0); err == nil {
+		t.Error("Rollback(0) reverted nothing without an error")
+	}
+}
+
+func TestMigratorMissingFiles(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+	if _, err := newMigrator(t, db, load(t, notes)).Migrate(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// The files of 2_add_body are gone
+	m := newMigrator(t, db, load(t, map[string]string{"1_create_notes.up.sql": notes["1_create_notes.up.sql"]}))
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(statuses) != 2 || statuses[0].Missing || !statuses[1].Missing || statuses[1].Name != "add_body" {
+		t.Fatalf("Status() = %+v, want 2_add_body applied with its files missing", statuses)
+	}
+	if _, err := m.Rollback(ctx, 1); err == nil {
+		t.Error("Rollback() reverted a migration without its files")
+	}
+}
+
+func TestMigratorRevertsFailedMigration(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+	m := newMigrator(t, db, load(t, map[string]string{
+		"1_create_notes.up.sql":   "CREATE TABLE notes (id INTEGER PRIMARY KEY);",
+		"1_create_notes.down.sql": "DROP TABLE notes;",
+		"2_broken.up.sql":         "CREATE TABLE tags (id INTEGER PRIMARY KEY);\nCREATE TABLE (;",
+	}))
+
+	done, err := m.Migrate(ctx)
+	if err == nil || !strings.Contains(err.Error(), "2_broken") {
+		t.Fatalf("Migrate() = %v, want 2_broken to fail", err)
+	}
+	if len(done) != 1 || done[0].Version != 1 {
+		t.Errorf("Migrate() = %v, want the migration before the broken one applied", done)
+	}
+	if tableExists(t, db, "tags") {
+		t.Error("the statements of the failed migration were not rolled back")
+	}
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(statuses) != 2 || !statuses[0].Applied || statuses[1].Applied {
+		t.Errorf("Status() = %+v, want the failed migration pending", statuses)
+	}
+	// The lock was released
+	if _, err := m.Rollback(ctx, 1); err != nil {
+		t.Errorf("Rollback() after a failure: %v", err)
+	}
+}
+
+func TestMigratorLock(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+	m := newMigrator(t, db, load(t, notes))
+
+	// An interrupted migrator left the lock behind
+	if _, err := db.Exec("CREATE TABLE " + LockTable + " (id INTEGER NOT NULL PRIMARY KEY, locked_at TEXT NOT NULL)"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec("INSERT INTO " + LockTable + " (id, locked_at) VALUES (1, 'then')"); err != nil {
+		t.Fatal(err)
+	}
+	waiting, cancel := context.WithTimeout(ctx, 300*time.Millisecond)
+	defer cancel()
+	if _, err := m.Migrate(waiting); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Migrate() = %v, want it to wait for the lock", err)
+	}
+	if tableExists(t, db, "notes") {
+		t.Fatal("Migrate() ran without the lock")
+	}
+
+	if _, err := db.Exec("DELETE FROM " + LockTable); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Migrate(ctx); err != nil {
+		t.Fatal(err)
+	}
+	var n int
+	if err := db.QueryRow("SELECT COUNT(*) FROM " + LockTable).Scan(&n); err != nil || n != 0 {
+		t.Errorf("the lock is still held after migrating: %d rows, %v", n, err)
+	}
+}
+
+func TestMigratorsRunOneAtATime(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+	migrations := load(t, notes)
+
+	migrators := make([]*Migrator, 4)
+	for i := range migrators {
+		migrators[i] = newMigrator(t, db, migrations)
+	}
+	var wg sync.WaitGroup
+	applied := make([]int, len(migrators))
+	errs := make([]error, len(migrators))
+	for i, m := range migrators {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			done, err := m.Migrate(ctx)
+			applied[i], errs[i] = len(done), err
+		}()
+	}
+	wg.Wait()
+
+	total := 0
+	for i, n := range applied {
+		if errs[i] != nil {
+			t.Errorf("migrator %d: %v", i, errs[i])
+		}
+		total += n
+	}
+	if total != len(migrations) {
+		t.Errorf("the migrators applied %v migrations, want each one applied once", applied)
+	}
+}
+
+func TestMigratorWithOneConnection(t *testing.T) {
+	// A migrator needing a second connection would wait for it until the deadline
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	db := openTestDB(t)
+	db.SetMaxOpenConns(1)
+	m := newMigrator(t, db, load(t, notes))
+
+	if done, err := m.Migrate(ctx); err != nil || len(done) != 2 {
+		t.Fatalf("Migrate() = %v, %v, want both migrations applied", done, err)
+	}
+	if done, err := m.Redo(ctx); err != nil || len(done) != 1 {
+		t.Fatalf("Redo() = %v, %v, want 2_add_body redone", done, err)
+	}
+	if done, err := m.Rollback(ctx, 2); err != nil || len(done) != 2 {
+		t.Fatalf("Rollback() = %v, %v, want both migrations reverted", done, err)
+	}
+	if statuses, err := m.Status(ctx); err != nil || len(statuses) != 2 || statuses[0].Applied {
+		t.Errorf("Status() = %v, %v, want two pending migrations", statuses, err)
+	}
+}
+
+func TestMigratorReportsChangedMigrations(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+	if _, err := newMigrator(t, db, load(t, notes)).Migrate(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	edited := map[string]string{}
+	for name, content := range notes {
+		edited[name] = content
+	}
+	// Line endings converted on checkout are not a change
+	edited["1_create_notes.up.sql"] = strings.ReplaceAll(notes["1_create_notes.up.sql"], "\n", "\r\n")
+	edited["2_add_body.up.sql"] = "ALTER TABLE notes ADD COLUMN body TEXT NOT NULL DEFAULT '';"
+	m := newMigrator(t, db, load(t, edited))
+
+	changed, err := m.Changed(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changed) != 1 || changed[0].Version != 2 {
+		t.Errorf("Changed() = %v, want 2_add_body", changed)
+	}
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if statuses[0].Changed || !statuses[1].Changed {
+		t.Errorf("Status() = %+v, want 2_add_body changed", statuses)
+	}
+
+	// Redoing it applies the edited file
+	if _, err := m.Redo(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if changed, err := m.Changed(ctx); err != nil || len(changed) != 0 {
+		t.Errorf("Changed() after Redo() = %v, %v, want none", changed, err)
+	}
+}
+
+func TestMigratorAddsChecksumColumn(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+	// The table of a migrator that did not record checksums
+	if _, err := db.Exec("CREATE TABLE " + Table + " (version BIGINT NOT NULL PRIMARY KEY, name VARCHAR(255) NOT NULL, applied_at VARCHAR(64) NOT NULL)"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec("CREATE TABLE notes (id INTEGER PRIMARY KEY)"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec("INSERT INTO "+Table+" (version, name, applied_at) VALUES (1, 'create_notes', ?)", time.Now().UTC().Format(time.RFC3339)); err != nil {
+		t.Fatal(err)
+	}
+
+	m := newMigrator(t, db, load(t, notes))
+	done, err := m.Migrate(ctx)
+	if err != nil || len(done) != 1 || done[0].Version != 2 {
+		t.Fatalf("Migrate() = %v, %v, want 2_add_body applied", done, err)
+	}
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(statuses) != 2 || !statuses[0].Applied || statuses[0].Changed || statuses[1].Changed {
+		t.Errorf("Status() = %+v, want both applied and unchanged", statuses)
+	}
+	var checksum string
+	if err := db.QueryRow("SELECT checksum FROM " + Table + " WHERE version = 2").Scan(&checksum); err != nil || checksum != done[0].Checksum() {
+		t.Errorf("checksum of 2_add_body = %q, %v, want %q", checksum, err, done[0].Checksum())
+	}
+}
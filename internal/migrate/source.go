@@ -0,0 +1,12 @@
+package migrate
+
+import _ "embed"
+
+// Source and TestSource are migrate.go and its tests, which gun copies into the projects it
+// generates so that apps migrate themselves with the same migrator as gun db.
+var (
+	//go:embed migrate.go
+	Source string
+	//go:embed migrate_test.go
+	TestSource string
+)
@@ -1,9 +1,30 @@
 package project
 
+import (
+	"github.com/theHamdiz/gun/internal/config"
+)
+
 type Project struct {
 	Name         string
 	ModuleName   string
 	WithChannels bool
 	WithSignals  bool
 	Style        string
+	Router       string
+	Dialect      string
+	Tags         []string
+}
+
+// Config returns the project configuration persisted for later generators.
+func (p Project) Config() config.Config {
+	return config.Config{
+		Name:         p.Name,
+		ModuleName:   p.ModuleName,
+		Style:        p.Style,
+		Router:       p.Router,
+		Dialect:      p.Dialect,
+		WithChannels: p.WithChannels,
+		WithSignals:  p.WithSignals,
+		Tags:         p.Tags,
+	}
 }
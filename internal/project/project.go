@@ -1,33 +1,58 @@
 package project
 
 import (
+	"errors"
 	"fmt"
-	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
 	"sync"
 
+	"github.com/theHamdiz/gun/internal/config"
 	"github.com/theHamdiz/gun/internal/generator"
 	"github.com/theHamdiz/it"
 )
 
-func CreateProject(name, moduleName, style string, withChannels, withSignals bool) error {
-	proj := Project{
-		Name:         name,
-		ModuleName:   moduleName,
-		Style:        style,
-		WithChannels: withChannels,
-		WithSignals:  withSignals,
+func CreateProject(proj Project) error {
+	if err := proj.Config().Validate(); err != nil {
+		return err
+	}
+	// The router decides which template pack the project is generated from
+	if err := generator.UsePack(proj.Router); err != nil {
+		return err
 	}
-
-	// Use a WaitGroup to handle asynchronous tasks
-	var wg sync.WaitGroup
-	var errChan = make(chan error, 7)
 
 	// Create the base project directory with the given project name
 	baseDir := strings.ToLower(proj.Name)
 
+	// A dry run never touches the disk, so there is nothing to stage or roll back
+	if generator.IsDryRun() {
+		return scaffold(baseDir, proj)
+	}
+
+	tx, err := beginTransaction(baseDir)
+	if err != nil {
+		return err
+	}
+	if err := scaffold(tx.stagingDir, proj); err != nil {
+		tx.rollback()
+		it.Errorf("Project creation failed, rolled back %s", baseDir)
+		return err
+	}
+	if err := tx.commit(); err != nil {
+		return err
+	}
+
+	it.Info("Project scaffolding complete.")
+	return nil
+}
+
+// scaffold generates the whole project tree into baseDir.
+func scaffold(baseDir string, proj Project) error {
+	// Use a WaitGroup to handle asynchronous tasks
+	var wg sync.WaitGroup
+	var errChan = make(chan error, 9)
+
 	// Create directories asynchronously
 	if err := createDirectories(baseDir); err != nil {
 		it.Errorf("Failed to create directories : %v", err)
@@ -86,6 +111,16 @@ func CreateProject(name, moduleName, style string, withChannels, withSignals boo
 		}
 	}()
 
+	// Persist the choices made here for every later generator
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if err := createConfigFile(baseDir, proj); err != nil {
+			it.Errorf("Failed to create the config file: %v", err)
+			errChan <- err
+		}
+	}()
+
 	// Setup styling configuration based on user choice
 	wg.Add(1)
 	go func() {
@@ -119,39 +154,24 @@ func CreateProject(name, moduleName, style string, withChannels, withSignals boo
 	wg.Wait()
 	close(errChan)
 
-	// Check for errors during setup
+	// Report every error that happened during setup, not just the first one
+	var errs []error
 	for err := range errChan {
-		if err != nil {
-			return err
-		}
+		errs = append(errs, err)
 	}
-
-	it.Info("Project scaffolding complete.")
-	return nil
+	return errors.Join(errs...)
 }
 
 func createDirectories(baseDir string) error {
 	it.Infof("Creating project in %s", baseDir)
 	// Attempt to create the base directory. If it exists, MkdirAll will not throw an error.
-	if err := os.MkdirAll(baseDir, 0755); err != nil {
+	if err := generator.MkdirAll(baseDir); err != nil {
 		it.Errorf("Failed to create base directory %s: %w", baseDir, err)
 		return fmt.Errorf("failed to create base directory %s: %w", baseDir, err)
 	}
-	dirs := []string{
-		filepath.Join(baseDir, "cmd/http/server"),
-		filepath.Join(baseDir, "cmd/http/api/v1"),
-		filepath.Join(baseDir, "internal/app"),
-		filepath.Join(baseDir, "internal/db"),
-		filepath.Join(baseDir, "internal/models"),
-		filepath.Join(baseDir, "internal/handlers"),
-		filepath.Join(baseDir, "internal/routes"),
-		filepath.Join(baseDir, "internal/middleware"),
-		filepath.Join(baseDir, "internal/views"),
-		filepath.Join(baseDir, "internal/utils"),
-	}
-
-	for _, dir := range dirs {
-		if err := os.MkdirAll(dir, 0755); err != nil {
+	// The template pack declares the directory layout of the project
+	for _, dir := range generator.ActivePack().Directories() {
+		if err := generator.MkdirAll(filepath.Join(baseDir, filepath.FromSlash(dir))); err != nil {
 			it.LogErrorWithStack(err)
 			return err
 		}
@@ -163,53 +183,48 @@ func initializeGoMod(baseDir, moduleName string) error {
 	cmd := exec.Command("go", "mod", "init", moduleName)
 	cmd.Dir = baseDir // Set the directory for go.mod initialization
 	it.Infof("Initializing go.mod in %s", baseDir)
-	return cmd.Run()
+	return generator.RunCommand(cmd)
 }
 
 func createServerMainFile(baseDir string, proj Project) error {
-	mainFilePath := filepath.Join(baseDir, "cmd", "http", "server", "main.go")
-	it.Infof("Creating main.go in %s", mainFilePath)
-	return generator.CreateFileFromTemplate(mainFilePath, generator.ServerMainTemplate, proj)
+	it.Infof("Creating main.go in %s", baseDir)
+	return generator.CreateFileFromLayout(baseDir, "server_main", proj)
 }
 
 func createAPIV1File(baseDir string, proj Project) error {
-	apiFilePath := filepath.Join(baseDir, "cmd", "http", "api", "v1", "apiv1.go")
-	return generator.CreateFileFromTemplate(apiFilePath, generator.APIV1Template, proj)
+	return generator.CreateFileFromLayout(baseDir, "apiv1", proj)
 }
 
 func createRouterFile(baseDir string, proj Project) error {
-	routerFilePath := filepath.Join(baseDir, "internal", "routes", "router.go")
-	return generator.CreateFileFromTemplate(routerFilePath, generator.RouterTemplate, proj)
+	return generator.CreateFileFromLayout(baseDir, "router", proj)
 }
 
 func createAppFile(baseDir string, proj Project) error {
-	appFilePath := filepath.Join(baseDir, "internal", "app", "app.go")
-	return generator.CreateFileFromTemplate(appFilePath, generator.AppTemplate, proj)
+	return generator.CreateFileFromLayout(baseDir, "app", proj)
 }
 
 func createUserFile(baseDir string, proj Project) error {
-	userFilePath := filepath.Join(baseDir, "internal", "models", "user.go")
-	return generator.CreateFileFromTemplate(userFilePath, generator.UserTemplate, proj)
+	return generator.CreateFileFromLayout(baseDir, "user", proj)
 }
 
-func createUtils(baseDir string, withChannels, withSignals bool) error {
-	utilsDir := filepath.Join(baseDir, "internal", "utils")
-	err := os.MkdirAll(utilsDir, 0755)
+func createConfigFile(baseDir string, proj Project) error {
+	data, err := proj.Config().Marshal()
 	if err != nil {
 		return err
 	}
+	return generator.WriteFile(filepath.Join(baseDir, config.Path), data)
+}
 
+func createUtils(baseDir string, withChannels, withSignals bool) error {
 	if withChannels {
-		channelsPath := filepath.Join(utilsDir, "channels.go")
-		err := generator.CreateFileFromTemplate(channelsPath, generator.ChannelsTemplate, nil)
+		err := generator.CreateFileFromLayout(baseDir, "channels", nil)
 		if err != nil {
 			return err
 		}
 	}
 
 	if withSignals {
-		signalsPath := filepath.Join(utilsDir, "signals.go")
-		err := generator.CreateFileFromTemplate(signalsPath, generator.SignalsTemplate, nil)
+		err := generator.CreateFileFromLayout(baseDir, "signals", nil)
 		if err != nil {
 			return err
 		}
@@ -244,21 +259,21 @@ func setupTailwind(baseDir string) error {
 	postInstallCmd.Dir = baseDir
 
 	// Install Node.js dependencies
-	if err := cmd.Run(); err != nil {
+	if err := generator.RunCommand(cmd); err != nil {
 		it.LogErrorWithStack(err)
 		return err
 	}
-	if err := installCmd.Run(); err != nil {
+	if err := generator.RunCommand(installCmd); err != nil {
 		it.LogErrorWithStack(err)
 		return err
 	}
-	if err := postInstallCmd.Run(); err != nil {
+	if err := generator.RunCommand(postInstallCmd); err != nil {
 		it.LogErrorWithStack(err)
 		return err
 	}
 
 	// Create Tailwind CSS input file
-	err := os.MkdirAll(filepath.Join(baseDir, "assets", "css"), 0755)
+	err := generator.MkdirAll(filepath.Join(baseDir, "assets", "css"))
 	if err != nil {
 		it.LogErrorWithStack(err)
 		return err
@@ -267,7 +282,7 @@ func setupTailwind(baseDir string) error {
 	inputCSSContent := `@tailwind base;
 @tailwind components;
 @tailwind utilities;`
-	if err := os.WriteFile(inputCSSPath, []byte(inputCSSContent), 0644); err != nil {
+	if err := generator.WriteFile(inputCSSPath, []byte(inputCSSContent)); err != nil {
 		it.LogErrorWithStack(err)
 		return err
 	}
@@ -293,7 +308,7 @@ func setupShadcnUI(baseDir string) error {
 	// Install shadcn/ui components (hypothetical example)
 	cmd := exec.Command("npm", "i", "@shadcn/ui")
 	cmd.Dir = baseDir
-	if err := cmd.Run(); err != nil {
+	if err := generator.RunCommand(cmd); err != nil {
 		it.LogErrorWithStack(err)
 		return err
 	}
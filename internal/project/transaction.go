@@ -0,0 +1,120 @@
+package project
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sync"
+	"syscall"
+
+	"github.com/theHamdiz/it"
+)
+
+// transaction stages a project in a temporary directory next to its target,
+// so that the target only ever appears once every step has succeeded.
+type transaction struct {
+	targetDir   string
+	stagingRoot string
+	stagingDir  string
+	signals     chan os.Signal
+	stopOnce    sync.Once
+}
+
+func beginTransaction(targetDir string) (*transaction, error) {
+	if err := ensureAvailable(targetDir); err != nil {
+		return nil, err
+	}
+
+	parent := filepath.Dir(targetDir)
+	if err := os.MkdirAll(parent, 0755); err != nil {
+		return nil, err
+	}
+
+	// Staging next to the target keeps the final rename on the same filesystem.
+	// The project keeps its own name inside the staging root, since tools like npm derive names from it.
+	stagingRoot, err := os.MkdirTemp(parent, ".gun-staging-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create staging directory: %w", err)
+	}
+
+	tx := &transaction{
+		targetDir:   targetDir,
+		stagingRoot: stagingRoot,
+		stagingDir:  filepath.Join(stagingRoot, filepath.Base(targetDir)),
+		signals:     make(chan os.Signal, 1),
+	}
+
+	// Roll back when interrupted, instead of leaving the staging directory behind.
+	signal.Notify(tx.signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		if _, ok := <-tx.signals; ok {
+			tx.rollback()
+			it.Warn("Interrupted, project creation rolled back.")
+			os.Exit(130)
+		}
+	}()
+
+	return tx, nil
+}
+
+// commit moves the staged project into place.
+func (tx *transaction) commit() error {
+	defer tx.stopSignals()
+
+	// The target may have been created as an empty directory in the meantime, or beforehand.
+	if err := ensureAvailable(tx.targetDir); err != nil {
+		tx.rollback()
+		return err
+	}
+	if err := os.Remove(tx.targetDir); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		tx.rollback()
+		return err
+	}
+
+	if err := os.Rename(tx.stagingDir, tx.targetDir); err != nil {
+		tx.rollback()
+		return fmt.Errorf("failed to move project into %s: %w", tx.targetDir, err)
+	}
+	return os.RemoveAll(tx.stagingRoot)
+}
+
+// rollback removes everything that was staged.
+func (tx *transaction) rollback() {
+	tx.stopSignals()
+	if err := os.RemoveAll(tx.stagingRoot); err != nil {
+		it.Errorf("Failed to remove staging directory %s: %v", tx.stagingRoot, err)
+	}
+}
+
+func (tx *transaction) stopSignals() {
+	tx.stopOnce.Do(func() {
+		signal.Stop(tx.signals)
+		close(tx.signals)
+	})
+}
+
+// ensureAvailable fails when targetDir exists and is anything but an empty directory.
+func ensureAvailable(targetDir string) error {
+	info, err := os.Stat(targetDir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s already exists and is not a directory", targetDir)
+	}
+
+	entries, err := os.ReadDir(targetDir)
+	if err != nil {
+		return err
+	}
+	if len(entries) > 0 {
+		return fmt.Errorf("%s already exists and is not empty", targetDir)
+	}
+	return nil
+}